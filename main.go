@@ -5,13 +5,17 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/moby/buildkit/client"
@@ -23,6 +27,8 @@ import (
 	"github.com/tsuru/deploy-agent/pkg/build/buildkit"
 	buildpb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
 	"github.com/tsuru/deploy-agent/pkg/health"
+	"github.com/tsuru/deploy-agent/pkg/signature"
+	"github.com/tsuru/deploy-agent/pkg/util"
 )
 
 const (
@@ -36,6 +42,20 @@ var cfg struct {
 	Port                 int
 	ServerMaxRecvMsgSize int
 	ServerMaxSendMsgSize int
+	RemoteArchiveMaxSize int64
+	ImageLabels          keyValueFlag
+	Containerfiles       keyValueFlag
+	AttestProvenance     string
+	SigningKey           string
+	CacheIDSecretFile    string
+	TsuruConfigDirs      stringSliceFlag
+	TsuruYamlNames       stringSliceFlag
+	ProcfileNames        stringSliceFlag
+	BuildEnvDeny         stringSliceFlag
+	ContextMaxSize       int64
+	ContextMaxFiles      int
+	ContextMaxRatio      int64
+	AttestSBOM           bool
 }
 
 func main() {
@@ -45,8 +65,70 @@ func main() {
 
 	flag.StringVar(&cfg.BuildkitAddress, "buildkit-addr", getEnvOrDefault("BUILDKIT_HOST", appdefaults.Address), "Buildkit server address")
 	flag.StringVar(&cfg.BuildkitTmpDir, "buildkit-tmp-dir", os.TempDir(), "Directory path to store temp files during container image builds")
+	flag.Int64Var(&cfg.RemoteArchiveMaxSize, "remote-archive-max-size", build.DefaultRemoteArchiveMaxSize, "Max size in bytes of app source archives downloaded from remote URLs")
+	flag.Var(&cfg.ImageLabels, "image-label", "Additional label (in key=template format) set on every built container image, may be used multiple times. Templates are rendered with the image metadata, e.g. com.example.team={{ .AppName }}-team")
+	flag.Var(&cfg.Containerfiles, "containerfile-template", "Template file (in key=path format) of the Containerfile generated on app source builds, may be used multiple times. Key is either the platform name (e.g. python) or a source image pattern (e.g. registry.example.com/tsuru/*:latest)")
+	flag.BoolVar(&cfg.AttestSBOM, "attest-sbom", false, "Generate the SBOM attestation of every built container image")
+	flag.StringVar(&cfg.AttestProvenance, "attest-provenance", "", "Generate the SLSA provenance attestation of every built container image in the given mode (min or max)")
+	flag.StringVar(&cfg.SigningKey, "signing-key", "", "Path to PEM-encoded private key used to sign the pushed container images (cosign format). Encrypted keys are decrypted with password from COSIGN_PASSWORD env var")
+	flag.StringVar(&cfg.CacheIDSecretFile, "cache-id-secret-file", "", "Path to file with the secret which app cache mount IDs are derived from, so apps can't guess each other's caches. When empty, a random secret is used, so caches don't survive agent restarts")
+	flag.Var(&cfg.TsuruConfigDirs, "tsuru-config-dir", "Additional absolute path searched for Procfile and Tsuru YAML, may be used multiple times")
+	flag.Var(&cfg.TsuruYamlNames, "tsuru-yaml-name", "Additional file name of Tsuru YAML, may be used multiple times")
+	flag.Var(&cfg.ProcfileNames, "procfile-name", "Additional file name of Procfile, may be used multiple times")
+	flag.Var(&cfg.BuildEnvDeny, "build-env-deny", "Pattern of app env var names (e.g. *_PASSWORD) left out of builds unless the app explicitly allows them, may be used multiple times")
+	flag.Int64Var(&cfg.ContextMaxSize, "context-max-size", util.DefaultExtractMaxSize, "Max size in bytes of uploaded build contexts (uncompressed)")
+	flag.IntVar(&cfg.ContextMaxFiles, "context-max-files", util.DefaultExtractMaxFiles, "Max number of files in uploaded build contexts")
+	flag.Int64Var(&cfg.ContextMaxRatio, "context-max-compression-ratio", util.DefaultExtractMaxCompressionRatio, "Max compression ratio of uploaded build contexts")
 	flag.Parse()
 
+	if err := buildkit.ValidateProvenanceMode(cfg.AttestProvenance); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid attest provenance: %v", err)
+		os.Exit(1)
+	}
+
+	tsuruConfigSearch := build.TsuruConfigSearch{
+		Dirs:           cfg.TsuruConfigDirs,
+		TsuruYamlNames: cfg.TsuruYamlNames,
+		ProcfileNames:  cfg.ProcfileNames,
+	}
+
+	if err := build.ValidateTsuruConfigSearch(tsuruConfigSearch); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid tsuru config search: %v", err)
+		os.Exit(1)
+	}
+
+	if err := build.ValidateBuildEnvDenyPatterns(cfg.BuildEnvDeny); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid build env deny patterns: %v", err)
+		os.Exit(1)
+	}
+
+	imageLabels, err := build.ParseImageLabelTemplates(cfg.ImageLabels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid image labels: %v", err)
+		os.Exit(1)
+	}
+
+	containerfileTemplates, err := build.LoadContainerfileTemplates(cfg.Containerfiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid containerfile templates: %v", err)
+		os.Exit(1)
+	}
+
+	var signer *signature.Signer
+	if cfg.SigningKey != "" {
+		signer, err = signature.LoadSigner(cfg.SigningKey, []byte(os.Getenv("COSIGN_PASSWORD")))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load signing key: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	cacheIDSecret, err := loadCacheIDSecret(cfg.CacheIDSecretFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load cache ID secret: %v", err)
+		os.Exit(1)
+	}
+
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to listen: %v", err)
@@ -68,7 +150,23 @@ func main() {
 	}
 
 	s := grpc.NewServer(serverOpts...)
-	buildpb.RegisterBuildServer(s, build.NewServer(buildkit.NewBuildKit(c, buildkit.BuildKitOptions{TempDir: cfg.BuildkitTmpDir})))
+	buildpb.RegisterBuildServer(s, build.NewServer(buildkit.NewBuildKit(c, buildkit.BuildKitOptions{
+		TempDir:                cfg.BuildkitTmpDir,
+		CacheIDSecret:          cacheIDSecret,
+		RemoteArchiveMaxSize:   cfg.RemoteArchiveMaxSize,
+		ImageLabels:            imageLabels,
+		ContainerfileTemplates: containerfileTemplates,
+		AttestSBOM:             cfg.AttestSBOM,
+		AttestProvenance:       cfg.AttestProvenance,
+		Signer:                 signer,
+		TsuruConfigSearch:      tsuruConfigSearch,
+		BuildEnvDenyPatterns:   cfg.BuildEnvDeny,
+		ContextExtraction: util.ExtractOptions{
+			MaxSize:             cfg.ContextMaxSize,
+			MaxFiles:            cfg.ContextMaxFiles,
+			MaxCompressionRatio: cfg.ContextMaxRatio,
+		},
+	})))
 	healthpb.RegisterHealthServer(s, health.NewServer())
 
 	go handleGracefulTermination(s)
@@ -94,6 +192,33 @@ func handleGracefulTermination(s *grpc.Server) {
 	<-stop
 }
 
+// loadCacheIDSecret reads the secret from filename, or generates a random one
+// when it's empty.
+func loadCacheIDSecret(filename string) ([]byte, error) {
+	if filename == "" {
+		fmt.Println("No cache ID secret file provided, app caches won't survive agent restarts")
+
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+
+		return secret, nil
+	}
+
+	secret, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	secret = bytes.TrimSpace(secret)
+	if len(secret) == 0 {
+		return nil, errors.New("cache ID secret cannot be empty")
+	}
+
+	return secret, nil
+}
+
 func getEnvOrDefault(env, def string) string {
 	if envvar, found := os.LookupEnv(env); found {
 		return envvar
@@ -101,3 +226,39 @@ func getEnvOrDefault(env, def string) string {
 
 	return def
 }
+
+type keyValueFlag map[string]string
+
+func (f *keyValueFlag) String() string {
+	var kvs []string
+	for k, v := range *f {
+		kvs = append(kvs, k+"="+v)
+	}
+
+	return strings.Join(kvs, ",")
+}
+
+func (f *keyValueFlag) Set(s string) error {
+	k, v, found := strings.Cut(s, "=")
+	if !found {
+		return fmt.Errorf("%q must be in key=value format", s)
+	}
+
+	if *f == nil {
+		*f = make(keyValueFlag)
+	}
+
+	(*f)[k] = v
+	return nil
+}
+
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+var provenanceModes = map[pb.ProvenanceMode]string{
+	pb.ProvenanceMode_PROVENANCE_MODE_MIN: "min",
+	pb.ProvenanceMode_PROVENANCE_MODE_MAX: "max",
+}
+
+// AttestationAttrs returns the BuildKit attrs of the attestations enabled by
+// either deploy-agent's config (sbom and provenance) or the build request,
+// i.e. requests can only add attestations. Provenance uses the most detailed
+// mode of both.
+func AttestationAttrs(sbom bool, provenance string, opts *pb.AttestationOptions) map[string]string {
+	if opts != nil {
+		sbom = sbom || opts.Sbom
+
+		if mode := provenanceModes[opts.Provenance]; provenanceModeRank(mode) > provenanceModeRank(provenance) {
+			provenance = mode
+		}
+	}
+
+	attrs := make(map[string]string)
+
+	if sbom {
+		attrs["attest:sbom"] = ""
+	}
+
+	if provenance != "" {
+		attrs["attest:provenance"] = "mode=" + provenance
+	}
+
+	return attrs
+}
+
+func provenanceModeRank(mode string) int {
+	switch mode {
+	case "min":
+		return 1
+	case "max":
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestAttestationAttrs(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		sbom       bool
+		provenance string
+		opts       *pb.AttestationOptions
+		expected   map[string]string
+	}{
+		"nothing enabled": {
+			expected: map[string]string{},
+		},
+
+		"config only": {
+			sbom:       true,
+			provenance: "min",
+			expected:   map[string]string{"attest:sbom": "", "attest:provenance": "mode=min"},
+		},
+
+		"request only": {
+			opts:     &pb.AttestationOptions{Sbom: true, Provenance: pb.ProvenanceMode_PROVENANCE_MODE_MAX},
+			expected: map[string]string{"attest:sbom": "", "attest:provenance": "mode=max"},
+		},
+
+		"request cannot disable sbom": {
+			sbom:     true,
+			opts:     &pb.AttestationOptions{Sbom: false},
+			expected: map[string]string{"attest:sbom": ""},
+		},
+
+		"request cannot disable provenance": {
+			provenance: "max",
+			opts:       &pb.AttestationOptions{Provenance: pb.ProvenanceMode_PROVENANCE_MODE_DISABLED},
+			expected:   map[string]string{"attest:provenance": "mode=max"},
+		},
+
+		"request raises provenance mode": {
+			provenance: "min",
+			opts:       &pb.AttestationOptions{Provenance: pb.ProvenanceMode_PROVENANCE_MODE_MAX},
+			expected:   map[string]string{"attest:provenance": "mode=max"},
+		},
+
+		"request cannot lower provenance mode": {
+			provenance: "max",
+			opts:       &pb.AttestationOptions{Provenance: pb.ProvenanceMode_PROVENANCE_MODE_MIN},
+			expected:   map[string]string{"attest:provenance": "mode=max"},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, AttestationAttrs(tt.sbom, tt.provenance, tt.opts))
+		})
+	}
+}
@@ -6,7 +6,10 @@ package build
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 
 	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
 )
@@ -23,4 +26,105 @@ var (
 
 type Builder interface {
 	Build(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error)
+	// Preview returns what Build would do on r, without solving nor pushing
+	// anything.
+	Preview(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error)
+}
+
+// TsuruConfigSearch holds additional locations of Procfile and tsuru.yaml.
+// Dirs are searched (in order) after the working dir and before
+// TsuruConfigDirs. Names are tried after the default ones.
+type TsuruConfigSearch struct {
+	Dirs           []string
+	TsuruYamlNames []string
+	ProcfileNames  []string
+}
+
+func NewTsuruConfigSearch(s *pb.TsuruConfigSearch) TsuruConfigSearch {
+	if s == nil {
+		return TsuruConfigSearch{}
+	}
+
+	return TsuruConfigSearch{
+		Dirs:           s.Dirs,
+		TsuruYamlNames: s.TsuruYamlNames,
+		ProcfileNames:  s.ProcfileNames,
+	}
+}
+
+func ValidateTsuruConfigSearch(s TsuruConfigSearch) error {
+	for _, dir := range s.Dirs {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("tsuru config search dir %q must be an absolute path", dir)
+		}
+	}
+
+	for _, name := range append(append([]string{}, s.TsuruYamlNames...), s.ProcfileNames...) {
+		if name == "" || name == "." || name == ".." || strings.ContainsRune(name, '/') {
+			return fmt.Errorf("tsuru config file name %q must be a base name", name)
+		}
+	}
+
+	return nil
+}
+
+// Merge returns the search with the locations of other appended, so they
+// have lower precedence.
+func (s TsuruConfigSearch) Merge(other TsuruConfigSearch) TsuruConfigSearch {
+	return TsuruConfigSearch{
+		Dirs:           appendUnique(s.Dirs, other.Dirs...),
+		TsuruYamlNames: appendUnique(s.TsuruYamlNames, other.TsuruYamlNames...),
+		ProcfileNames:  appendUnique(s.ProcfileNames, other.ProcfileNames...),
+	}
+}
+
+func (s TsuruConfigSearch) dirs(workingDir string) []string {
+	dirs := make([]string, 0, len(s.Dirs)+len(TsuruConfigDirs)+1)
+
+	if workingDir != "" {
+		dirs = append(dirs, workingDir) // added first to get higher precedence
+	}
+
+	for _, dir := range s.Dirs {
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+
+	return appendUnique(dirs, TsuruConfigDirs...)
+}
+
+func (s TsuruConfigSearch) tsuruYamlNames() []string {
+	return appendUnique(TsuruYamlNames, s.TsuruYamlNames...)
+}
+
+func (s TsuruConfigSearch) procfileNames() []string {
+	return appendUnique([]string{ProcfileName}, s.ProcfileNames...)
+}
+
+func (s TsuruConfigSearch) isTsuruYaml(filename string) bool {
+	return containsString(s.tsuruYamlNames(), filepath.Base(filename))
+}
+
+func (s TsuruConfigSearch) isProcfile(filename string) bool {
+	return containsString(s.procfileNames(), filepath.Base(filename))
+}
+
+func appendUnique(dst []string, values ...string) []string {
+	result := append([]string{}, dst...)
+	for _, v := range values {
+		if !containsString(result, v) {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
 }
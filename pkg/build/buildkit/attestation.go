@@ -0,0 +1,105 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildkit
+
+import (
+	"context"
+	"fmt"
+
+	containerregistryremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+
+	"github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+const (
+	// See more: https://github.com/moby/buildkit/blob/v0.11.3/docs/attestations/attestation-storage.md
+	attestationManifestReferenceTypeAnnotation   = "vnd.docker.reference.type"
+	attestationManifestReferenceDigestAnnotation = "vnd.docker.reference.digest"
+	attestationManifestType                      = "attestation-manifest"
+	inTotoPredicateTypeAnnotation                = "in-toto.io/predicate-type"
+)
+
+func ValidateProvenanceMode(mode string) error {
+	switch mode {
+	case "", "min", "max":
+		return nil
+	}
+
+	return fmt.Errorf("invalid provenance mode %q: must be either min or max", mode)
+}
+
+func (b *BuildKit) attestationAttrs(r *pb.BuildRequest) map[string]string {
+	return build.AttestationAttrs(b.opts.AttestSBOM, b.opts.AttestProvenance, r.Attestations)
+}
+
+func (b *BuildKit) extractAttestations(ctx context.Context, r *pb.BuildRequest, resp *client.SolveResponse) ([]*pb.Attestation, error) {
+	if len(b.attestationAttrs(r)) == 0 || resp == nil {
+		return nil, nil
+	}
+
+	var insecureRegistry bool
+	if pots := r.PushOptions; pots != nil {
+		if pots.Disable { // attestations only live in the container registry
+			return nil, nil
+		}
+
+		insecureRegistry = pots.InsecureRegistry
+	}
+
+	digest, found := resp.ExporterResponse[exptypes.ExporterImageDigestKey]
+	if !found {
+		return nil, nil
+	}
+
+	ref, remoteOpts, err := containerRegistryReference(ctx, r.DestinationImages[0], insecureRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := containerregistryremote.Index(ref.Context().Digest(digest), remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image index of %s: %w", digest, err)
+	}
+
+	im, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var attestations []*pb.Attestation
+	for _, desc := range im.Manifests {
+		if desc.Annotations[attestationManifestReferenceTypeAnnotation] != attestationManifestType {
+			continue
+		}
+
+		image, nerr := index.Image(desc.Digest)
+		if nerr != nil {
+			return nil, nerr
+		}
+
+		m, nerr := image.Manifest()
+		if nerr != nil {
+			return nil, nerr
+		}
+
+		var predicateTypes []string
+		for _, l := range m.Layers {
+			if pt, ok := l.Annotations[inTotoPredicateTypeAnnotation]; ok {
+				predicateTypes = append(predicateTypes, pt)
+			}
+		}
+
+		attestations = append(attestations, &pb.Attestation{
+			Digest:         desc.Digest.String(),
+			SubjectDigest:  desc.Annotations[attestationManifestReferenceDigestAnnotation],
+			PredicateTypes: predicateTypes,
+		})
+	}
+
+	return attestations, nil
+}
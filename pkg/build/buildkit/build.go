@@ -5,25 +5,31 @@
 package buildkit
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/alessio/shellescape"
 	"github.com/containerd/console"
 	"github.com/docker/cli/cli/config"
+	"github.com/docker/go-units"
 	containerregistryauthn "github.com/google/go-containerregistry/pkg/authn"
 	containerregistryname "github.com/google/go-containerregistry/pkg/name"
+	containerregistryv1 "github.com/google/go-containerregistry/pkg/v1"
 	containerregistrygoogle "github.com/google/go-containerregistry/pkg/v1/google"
 	containerregistryremote "github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	gateway "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/session/auth/authprovider"
@@ -32,17 +38,34 @@ import (
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"gopkg.in/yaml.v3"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/tsuru/deploy-agent/pkg/build"
+	"github.com/tsuru/deploy-agent/pkg/build/buildkit/progress"
 	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+	"github.com/tsuru/deploy-agent/pkg/signature"
 	"github.com/tsuru/deploy-agent/pkg/util"
 )
 
 var _ build.Builder = (*BuildKit)(nil)
 
+const (
+	tsuruIgnoreFile  = ".tsuruignore"  // applied on app source data
+	dockerIgnoreFile = ".dockerignore" // applied on container file contexts
+)
+
 type BuildKitOptions struct {
-	TempDir string
+	ImageLabels            build.ImageLabelTemplates
+	ContainerfileTemplates build.ContainerfileTemplates // replace the built-in Containerfile of app source builds
+	Signer                 *signature.Signer            // signs the pushed images, if set
+	TsuruConfigSearch      build.TsuruConfigSearch
+	ContextExtraction      util.ExtractOptions // limits of uploaded container file contexts
+	TempDir                string
+	CacheIDSecret          []byte // keys the cache mount IDs of apps (see build.CacheMountID)
+	AttestProvenance       string // either empty (disabled), "min" or "max"
+	RemoteArchiveMaxSize   int64
+	BuildEnvDenyPatterns   []string // names of app env vars (e.g. *_PASSWORD) left out of builds, unless the app allows them
+	AttestSBOM             bool
 }
 
 type BuildKit struct {
@@ -81,75 +104,156 @@ func (b *BuildKit) Build(ctx context.Context, r *pb.BuildRequest, w io.Writer) (
 	return nil, status.Errorf(codes.Unimplemented, "build kind not supported")
 }
 
-func (b *BuildKit) buildFromAppSourceFiles(ctx context.Context, r *pb.BuildRequest, w console.File) (*pb.TsuruConfig, error) {
+// appSourceBuild holds what's sent to BuildKit on app source builds.
+type appSourceBuild struct {
+	archive       *util.Archive
+	appFiles      *pb.TsuruConfig
+	params        build.BuildContainerfileParams
+	containerfile string
+}
+
+// prepareAppSourceBuild reads the app source data (downloading it, if needed),
+// looks up the Tsuru config files and renders the Containerfile.
+func (b *BuildKit) prepareAppSourceBuild(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*appSourceBuild, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	appFiles, err := build.ExtractTsuruAppFilesFromAppSourceContext(ctx, bytes.NewBuffer(r.Data))
+	data := r.Data
+	if r.RemoteArchive != nil {
+		fmt.Fprintln(w, "Downloading app source data from", build.RemoteArchiveLocation(r.RemoteArchive))
+
+		var err error
+		data, err = build.DownloadRemoteArchive(ctx, r.RemoteArchive, build.RemoteArchiveOptions{MaxSize: b.opts.RemoteArchiveMaxSize})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	archive, err := util.NewArchive(data)
 	if err != nil {
 		return nil, err
 	}
+	archive = archive.WithLimits(b.opts.ContextExtraction)
 
-	var dockerfile bytes.Buffer
-	if err = generateContainerfile(&dockerfile, r.SourceImage, appFiles); err != nil {
+	// Files the user never meant to ship (e.g. .git, node_modules) are left
+	// out of the build context, same as BuildKit does with .dockerignore.
+	// That's done before looking up the Tsuru config files, so ignored ones
+	// aren't picked either.
+	if archive, err = excludeIgnoredFiles(ctx, w, archive, tsuruIgnoreFile); err != nil {
 		return nil, err
 	}
 
-	var envs map[string]string
-	if r.App != nil {
-		envs = r.App.EnvVars
+	params := b.newBuildContainerfileParams(ctx, r, w)
+
+	appFiles, err := build.ExtractTsuruAppFilesFromAppSourceContext(ctx, archive, params.Platform.WorkingDir, b.tsuruConfigSearch(r))
+	if err != nil {
+		return nil, err
 	}
 
-	tmpDir, cleanFunc, err := generateBuildLocalDir(ctx, b.opts.TempDir, dockerfile.String(), bytes.NewBuffer(r.Data), envs, nil)
+	tsuruYaml, warnings, err := build.ParseTsuruYaml(appFiles.TsuruYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	printTsuruYamlWarnings(w, warnings)
+
+	if hooks := tsuruYaml.Hooks; hooks != nil {
+		params.BuildHooks = hooks.Build
+	}
+
+	containerfile, err := build.BuildContainerfileFromTemplate(b.opts.ContainerfileTemplates.Find(params.PlatformName, params.Image), params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appSourceBuild{archive: archive, appFiles: appFiles, params: params, containerfile: containerfile}, nil
+}
+
+func (b *BuildKit) buildFromAppSourceFiles(ctx context.Context, r *pb.BuildRequest, w console.File) (*pb.TsuruConfig, error) {
+	asb, err := b.prepareAppSourceBuild(ctx, r, w)
+	if err != nil {
+		return nil, err
+	}
+
+	appFiles := asb.appFiles
+
+	envs := b.buildEnvVars(r, w)
+
+	if asb.archive.Format() != util.ArchiveFormatTarGZIP { // platform's deploy script only reads tar.gz files
+		fmt.Fprintf(w, "Converting app source data from %s to tar.gz\n", asb.archive.Format())
+	}
+
+	tmpDir, cleanFunc, err := b.generateBuildLocalDir(ctx, w, asb.containerfile, asb.archive, b.appArchiveCompressOptions(r), envs, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer cleanFunc()
 
-	if err = b.callBuildKitBuild(ctx, tmpDir, r, w); err != nil {
+	summary := progress.NewSummary()
+
+	resp, tc, err := b.callBuildKitBuild(ctx, tmpDir, r, w, summary)
+	if err != nil {
+		return nil, build.NewBuildHookError(err, asb.params, summary.Duration)
+	}
+
+	if appFiles.Attestations, err = b.extractAttestations(ctx, r, resp); err != nil {
 		return nil, err
 	}
 
 	// NOTE(nettoclaudio): Some platforms don't require an user-defined Procfile (e.g. go, java, static, etc).
 	// So we need to retrieve the default Procfile from the platform image.
 	if appFiles.Procfile == "" {
-		fmt.Fprintln(w, "User-defined Procfile not found, trying to extract it from platform's container image")
-
-		tc, err := b.extractTsuruConfigsFromContainerImage(ctx, r.DestinationImages[0], build.DefaultTsuruPlatformWorkingDir)
-		if err != nil {
-			return nil, err
-		}
-
-		appFiles.Procfile = tc.Procfile
+		fmt.Fprintln(w, "User-defined Procfile not found, using the one from platform's container image")
+		appFiles.Procfile, appFiles.ProcfilePath = tc.Procfile, tc.ProcfilePath
 	}
 
 	return appFiles, nil
 }
 
-func generateContainerfile(w io.Writer, image string, tsuruAppFiles *pb.TsuruConfig) error {
-	var tsuruYaml build.TsuruYamlData
-	if tsuruAppFiles != nil {
-		if err := yaml.Unmarshal([]byte(tsuruAppFiles.TsuruYaml), &tsuruYaml); err != nil {
-			return err
-		}
+// newBuildContainerfileParams returns the params of Containerfile, including
+// the platform's metadata and cache dirs declared through labels of its
+// container image (source image). Reading labels is not critical, so failures
+// are only reported in the output and the defaults are used instead.
+func (b *BuildKit) newBuildContainerfileParams(ctx context.Context, r *pb.BuildRequest, w io.Writer) build.BuildContainerfileParams {
+	params := build.BuildContainerfileParams{
+		Image:         r.SourceImage,
+		AppName:       r.App.GetName(),
+		CacheIDSecret: b.opts.CacheIDSecret,
+		PlatformName:  r.App.GetPlatform(),
+		Platform:      build.DefaultPlatformMetadata(),
 	}
 
-	var buildHooks []string
-	if hooks := tsuruYaml.Hooks; hooks != nil {
-		buildHooks = hooks.Build
+	if opts := r.BuildHooks; opts != nil {
+		params.SeparateBuildHookSteps = opts.SeparateSteps
+		params.BuildHookTimeout = opts.Timeout.AsDuration()
 	}
 
-	dockerfile, err := build.BuildContainerfile(build.BuildContainerfileParams{
-		Image:      image,
-		BuildHooks: buildHooks,
-	})
+	var insecureRegistry bool
+	if r.PushOptions != nil {
+		insecureRegistry = r.PushOptions.InsecureRegistry
+	}
+
+	labels, err := containerImageLabels(ctx, r.SourceImage, insecureRegistry)
 	if err != nil {
-		return err
+		fmt.Fprintf(w, "Could not read the labels of source image %s, using the default platform's settings: %s\n", r.SourceImage, err)
+		return params
 	}
 
-	_, err = io.WriteString(w, dockerfile)
-	return err
+	params.SourceImageLabels = labels
+
+	var problems []string
+	params.Platform, problems = build.ParsePlatformMetadata(labels)
+	for _, p := range problems {
+		fmt.Fprintln(w, "Ignoring invalid platform's label:", p)
+	}
+
+	params.CacheDirs, problems = build.ParseCacheDirs(labels[build.CacheDirsLabel])
+	for _, p := range problems {
+		fmt.Fprintln(w, "Ignoring invalid platform's cache dir:", p)
+	}
+
+	return params
 }
 
 func (b *BuildKit) buildFromContainerImage(ctx context.Context, r *pb.BuildRequest, w console.File) (*pb.TsuruConfig, error) {
@@ -157,109 +261,328 @@ func (b *BuildKit) buildFromContainerImage(ctx context.Context, r *pb.BuildReque
 		return nil, err
 	}
 
-	tmpDir, cleanFunc, err := generateBuildLocalDir(ctx, b.opts.TempDir, fmt.Sprintf("FROM %s", r.SourceImage), nil, nil, nil)
+	tmpDir, cleanFunc, err := b.generateBuildLocalDir(ctx, w, fmt.Sprintf("FROM %s", r.SourceImage), nil, util.CompressOptions{}, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer cleanFunc()
 
-	if err = b.callBuildKitBuild(ctx, tmpDir, r, w); err != nil {
+	resp, appFiles, err := b.callBuildKitBuild(ctx, tmpDir, r, w, progress.NewSummary())
+	if err != nil {
 		return nil, err
 	}
 
-	var insecureRegistry bool
-	if r.PushOptions != nil {
-		insecureRegistry = r.PushOptions.InsecureRegistry
+	if err = completeContainerImageConfig(ctx, r, resp, appFiles.ImageConfig); err != nil {
+		return nil, err
+	}
+
+	deriveProcfileFromImageConfig(appFiles, w)
+
+	if appFiles.Attestations, err = b.extractAttestations(ctx, r, resp); err != nil {
+		return nil, err
+	}
+
+	return appFiles, nil
+}
+
+func printTsuruYamlWarnings(w io.Writer, warnings []build.TsuruYamlProblem) {
+	for _, p := range warnings {
+		if p.Line == 0 {
+			fmt.Fprintf(w, "Warning: tsuru.yaml: %s\n", p.Message)
+			continue
+		}
+
+		fmt.Fprintf(w, "Warning: tsuru.yaml line %d: %s\n", p.Line, p.Message)
 	}
+}
 
-	imageConfig, err := extractContainerImageConfigFromImageManifest(ctx, r.DestinationImages[0], insecureRegistry)
+// validatesTsuruYamlFromImage returns whether the tsuru.yaml found in the built
+// image is the app's one, i.e. it isn't taken from the uploaded source files.
+func validatesTsuruYamlFromImage(r *pb.BuildRequest) bool {
+	return r.Kind == pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_CONTAINER_IMAGE || r.Kind == pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_CONTAINER_FILE
+}
+
+func deriveProcfileFromImageConfig(tc *pb.TsuruConfig, w io.Writer) {
+	if tc.Procfile != "" {
+		return
+	}
+
+	procfile := build.DeriveProcfile(tc.ImageConfig)
+	if procfile == "" {
+		return
+	}
+
+	fmt.Fprintln(w, "Procfile not found, using the container image's entrypoint and command as web process")
+
+	tc.Procfile, tc.ProcfileDerived = procfile, true
+}
+
+// buildEnvVars returns the app env vars written to the secrets/envs.sh file.
+func (b *BuildKit) buildEnvVars(r *pb.BuildRequest, w io.Writer) map[string]string {
+	envs, denied := build.BuildEnvVars(r.App, b.opts.BuildEnvDenyPatterns)
+	if len(denied) > 0 {
+		fmt.Fprintf(w, "Leaving env vars out of the build (matched by deny patterns): %s\n", strings.Join(denied, ", "))
+	}
+
+	return envs
+}
+
+func (b *BuildKit) tsuruConfigSearch(r *pb.BuildRequest) build.TsuruConfigSearch {
+	return build.NewTsuruConfigSearch(r.TsuruConfigSearch).Merge(b.opts.TsuruConfigSearch)
+}
+
+// isNotFound returns whether err is a not found error from BuildKit gateway,
+// which may come as either codes.NotFound or an ENOENT message.
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return status.Code(err) == codes.NotFound || errors.Is(err, fs.ErrNotExist) || strings.Contains(err.Error(), syscall.ENOENT.Error())
+}
+
+// extractTsuruConfigsFromResult reads only the candidate paths of Procfile
+// and tsuru.yaml from the solved container image, so there's no need to export
+// its whole filesystem. The container image config is taken from the result as
+// well, so it doesn't depend on the image being pushed.
+func extractTsuruConfigsFromResult(ctx context.Context, res *gateway.Result, search build.TsuruConfigSearch) (*pb.TsuruConfig, error) {
+	var cf containerregistryv1.ConfigFile
+	if data, found := res.Metadata[exptypes.ExporterImageConfigKey]; found {
+		if err := json.Unmarshal(data, &cf); err != nil {
+			return nil, fmt.Errorf("failed to decode container image config: %w", err)
+		}
+	}
+
+	ic, err := newContainerImageConfig(&cf, "", 0)
 	if err != nil {
 		return nil, err
 	}
 
-	appFiles, err := b.callBuildKitToExtractTsuruConfigs(ctx, tmpDir, imageConfig.WorkingDir)
+	ref, err := res.SingleRef()
 	if err != nil {
 		return nil, err
 	}
 
-	appFiles.ImageConfig = imageConfig
-	return appFiles, nil
-}
+	if ref == nil { // empty filesystem, e.g. FROM scratch
+		return &pb.TsuruConfig{ImageConfig: ic}, nil
+	}
+
+	tc, err := build.ExtractTsuruAppFiles(ctx, cf.Config.WorkingDir, search, func(ctx context.Context, filename string) ([]byte, error) {
+		st, nerr := ref.StatFile(ctx, gateway.StatRequest{Path: filename})
+		if isNotFound(nerr) {
+			return nil, fs.ErrNotExist
+		}
+
+		if nerr != nil {
+			return nil, nerr
+		}
 
-func (b *BuildKit) extractTsuruConfigsFromContainerImage(ctx context.Context, image, workingDir string) (*pb.TsuruConfig, error) {
-	tmpDir, cleanFunc, err := generateBuildLocalDir(ctx, b.opts.TempDir, fmt.Sprintf("FROM %s", image), nil, nil, nil)
+		if !os.FileMode(st.Mode).IsRegular() {
+			return nil, fs.ErrNotExist
+		}
+
+		return ref.ReadFile(ctx, gateway.ReadRequest{Filename: filename, Range: &gateway.FileRange{Length: build.MaxTsuruConfigSize + 1}})
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer cleanFunc()
 
-	return b.callBuildKitToExtractTsuruConfigs(ctx, tmpDir, workingDir)
+	tc.ImageConfig = ic
+	return tc, nil
 }
 
-func (b *BuildKit) callBuildKitToExtractTsuruConfigs(ctx context.Context, localContextDir, workingDir string) (*pb.TsuruConfig, error) {
-	eg, ctx := errgroup.WithContext(ctx)
-	pr, pw := io.Pipe() // reader/writer for tar output
+// completeContainerImageConfig sets the digest of the exported container
+// image and, when it was pushed, its size from the container registry.
+func completeContainerImageConfig(ctx context.Context, r *pb.BuildRequest, resp *client.SolveResponse, ic *pb.ContainerImageConfig) error {
+	digest, found := resp.ExporterResponse[exptypes.ExporterImageDigestKey]
+	if !found {
+		return status.Error(codes.Internal, "container image digest not found in the build response")
+	}
 
-	eg.Go(func() error {
-		opts := client.SolveOpt{
-			Frontend: "dockerfile.v0",
-			LocalDirs: map[string]string{
-				"context":    filepath.Join(localContextDir, "context"),
-				"dockerfile": localContextDir,
-			},
-			Exports: []client.ExportEntry{
-				{
-					Type: client.ExporterTar,
-					Output: func(_ map[string]string) (io.WriteCloser, error) {
-						return pw, nil
-					},
-				},
-			},
-			Session: []session.Attachable{
-				authprovider.NewDockerAuthProvider(config.LoadDefaultConfigFile(os.Stderr)),
-			},
+	ic.Digest = digest
+
+	var insecureRegistry bool
+	if pots := r.PushOptions; pots != nil {
+		if pots.Disable { // size is only known after compressing layers on push
+			return nil
 		}
-		_, err := b.cli.Build(ctx, opts, "deploy-agent", func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
-			return c.Solve(ctx, gateway.SolveRequest{
-				Frontend:    opts.Frontend,
-				FrontendOpt: opts.FrontendAttrs,
-			})
-		}, nil)
-		return err
-	})
 
-	var tc *pb.TsuruConfig
-	eg.Go(func() error {
-		var err error
-		tc, err = build.ExtractTsuruAppFilesFromContainerImageTarball(ctx, pr, workingDir)
+		insecureRegistry = pots.InsecureRegistry
+	}
+
+	size, err := containerImageSize(ctx, r.DestinationImages[0], digest, insecureRegistry)
+	if err != nil {
 		return err
-	})
+	}
+
+	ic.Size = size
+	return nil
+}
+
+// containerImageSize returns the size of image's config and layers. When
+// digest is an image index (e.g. the image has attestations), that's the sum
+// over its child images, leaving the attestation manifests out.
+func containerImageSize(ctx context.Context, imageStr, digest string, insecureRegistry bool) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	ref, remoteOpts, err := containerRegistryReference(ctx, imageStr, insecureRegistry)
+	if err != nil {
+		return 0, err
+	}
+
+	desc, err := containerregistryremote.Get(ref.Context().Digest(digest), remoteOpts...)
+	if err != nil {
+		return 0, err
+	}
+
+	if !desc.MediaType.IsIndex() {
+		image, nerr := desc.Image()
+		if nerr != nil {
+			return 0, nerr
+		}
+
+		return imageSize(image)
+	}
+
+	index, err := desc.ImageIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	im, err := index.IndexManifest()
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, d := range im.Manifests {
+		if d.Annotations[attestationManifestReferenceTypeAnnotation] == attestationManifestType || !d.MediaType.IsImage() {
+			continue
+		}
+
+		image, nerr := index.Image(d.Digest)
+		if nerr != nil {
+			return 0, nerr
+		}
+
+		n, nerr := imageSize(image)
+		if nerr != nil {
+			return 0, nerr
+		}
+
+		size += n
+	}
 
-	if err := eg.Wait(); err != nil {
+	return size, nil
+}
+
+func imageSize(image containerregistryv1.Image) (int64, error) {
+	m, err := image.Manifest()
+	if err != nil {
+		return 0, err
+	}
+
+	size := m.Config.Size
+	for _, l := range m.Layers {
+		size += l.Size
+	}
+
+	return size, nil
+}
+
+func newContainerImageConfig(cf *containerregistryv1.ConfigFile, digest string, size int64) (*pb.ContainerImageConfig, error) {
+	exposedPorts := make([]string, 0, len(cf.Config.ExposedPorts))
+	for k := range cf.Config.ExposedPorts {
+		exposedPorts = append(exposedPorts, k)
+	}
+
+	sort.Strings(exposedPorts)
+
+	ports, err := build.ParseExposedPorts(exposedPorts)
+	if err != nil {
 		return nil, err
 	}
 
-	return tc, nil
+	volumes := make([]string, 0, len(cf.Config.Volumes))
+	for k := range cf.Config.Volumes {
+		volumes = append(volumes, k)
+	}
+
+	sort.Strings(volumes)
+
+	var healthcheck *pb.ContainerImageHealthcheck
+	if hc := cf.Config.Healthcheck; hc != nil {
+		healthcheck = &pb.ContainerImageHealthcheck{
+			Test:    hc.Test,
+			Retries: int32(hc.Retries),
+		}
+
+		if hc.Interval > 0 {
+			healthcheck.Interval = durationpb.New(hc.Interval)
+		}
+
+		if hc.Timeout > 0 {
+			healthcheck.Timeout = durationpb.New(hc.Timeout)
+		}
+
+		if hc.StartPeriod > 0 {
+			healthcheck.StartPeriod = durationpb.New(hc.StartPeriod)
+		}
+	}
+
+	ic := &pb.ContainerImageConfig{
+		Entrypoint:  cf.Config.Entrypoint,
+		Cmd:         cf.Config.Cmd,
+		WorkingDir:  cf.Config.WorkingDir,
+		Env:         cf.Config.Env,
+		User:        cf.Config.User,
+		Labels:      cf.Config.Labels,
+		StopSignal:  cf.Config.StopSignal,
+		Healthcheck: healthcheck,
+		Digest:      digest,
+		Size:        size,
+		Ports:       ports,
+	}
+
+	if len(exposedPorts) > 0 {
+		ic.ExposedPorts = exposedPorts
+	}
+
+	if len(volumes) > 0 {
+		ic.Volumes = volumes
+	}
+
+	return ic, nil
 }
 
-func extractContainerImageConfigFromImageManifest(ctx context.Context, imageStr string, insecureRegistry bool) (*pb.ContainerImageConfig, error) {
+func resolveContainerImageDigest(ctx context.Context, imageStr string, insecureRegistry bool) (string, error) {
 	if err := ctx.Err(); err != nil {
-		return nil, err
+		return "", err
 	}
 
-	var nameOpts []containerregistryname.Option
-	if insecureRegistry {
-		nameOpts = append(nameOpts, containerregistryname.Insecure)
+	ref, remoteOpts, err := containerRegistryReference(ctx, imageStr, insecureRegistry)
+	if err != nil {
+		return "", err
 	}
 
-	ref, err := containerregistryname.ParseReference(imageStr, nameOpts...)
+	desc, err := containerregistryremote.Head(ref, remoteOpts...)
 	if err != nil {
+		return "", err
+	}
+
+	return desc.Digest.String(), nil
+}
+
+func containerImageLabels(ctx context.Context, imageStr string, insecureRegistry bool) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	remoteOpts := []containerregistryremote.Option{
-		containerregistryremote.WithContext(ctx),
-		containerregistryremote.WithAuthFromKeychain(containerregistryauthn.NewMultiKeychain(containerregistryauthn.DefaultKeychain, containerregistrygoogle.Keychain)),
+	ref, remoteOpts, err := containerRegistryReference(ctx, imageStr, insecureRegistry)
+	if err != nil {
+		return nil, err
 	}
 
 	image, err := containerregistryremote.Image(ref, remoteOpts...)
@@ -272,20 +595,29 @@ func extractContainerImageConfigFromImageManifest(ctx context.Context, imageStr
 		return nil, err
 	}
 
-	var exposedPorts []string
-	for k := range cf.Config.ExposedPorts {
-		exposedPorts = append(exposedPorts, k)
+	return cf.Config.Labels, nil
+}
+
+func containerRegistryReference(ctx context.Context, imageStr string, insecureRegistry bool) (containerregistryname.Reference, []containerregistryremote.Option, error) {
+	var nameOpts []containerregistryname.Option
+	if insecureRegistry {
+		nameOpts = append(nameOpts, containerregistryname.Insecure)
+	}
+
+	ref, err := containerregistryname.ParseReference(imageStr, nameOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remoteOpts := []containerregistryremote.Option{
+		containerregistryremote.WithContext(ctx),
+		containerregistryremote.WithAuthFromKeychain(containerregistryauthn.NewMultiKeychain(containerregistryauthn.DefaultKeychain, containerregistrygoogle.Keychain)),
 	}
 
-	return &pb.ContainerImageConfig{
-		Entrypoint:   cf.Config.Entrypoint,
-		Cmd:          cf.Config.Cmd,
-		WorkingDir:   cf.Config.WorkingDir,
-		ExposedPorts: exposedPorts,
-	}, nil
+	return ref, remoteOpts, nil
 }
 
-func generateBuildLocalDir(ctx context.Context, baseDir, dockerfile string, appArchiveData io.Reader, envs map[string]string, files io.Reader) (string, func(), error) {
+func (b *BuildKit) generateBuildLocalDir(ctx context.Context, w io.Writer, dockerfile string, appArchive *util.Archive, appArchiveOpts util.CompressOptions, envs map[string]string, files *util.Archive) (string, func(), error) {
 	noopFunc := func() {}
 
 	if err := ctx.Err(); err != nil {
@@ -303,7 +635,14 @@ func generateBuildLocalDir(ctx context.Context, baseDir, dockerfile string, appA
 	//     ...
 	//     [other files]
 
-	rootDir, err := os.MkdirTemp(baseDir, "deploy-agent-*")
+	// NOTE: appArchive was already filtered by .tsuruignore, see
+	// prepareAppSourceBuild.
+	var err error
+	if files, err = excludeIgnoredFiles(ctx, w, files, dockerIgnoreFile); err != nil {
+		return "", noopFunc, err
+	}
+
+	rootDir, err := os.MkdirTemp(b.opts.TempDir, "deploy-agent-*")
 	if err != nil {
 		return "", noopFunc, status.Errorf(codes.Internal, "failed to create temp dir: %s", err)
 	}
@@ -331,16 +670,15 @@ func generateBuildLocalDir(ctx context.Context, baseDir, dockerfile string, appA
 	})
 
 	eg.Go(func() error {
-		if appArchiveData == nil { // there's no application.tar.gz file, skipping it
+		if appArchive == nil { // there's no application.tar.gz file, skipping it
 			return nil
 		}
-		appArchive, nerr := os.Create(filepath.Join(contextDir, "application.tar.gz"))
+		f, nerr := os.Create(filepath.Join(contextDir, "application.tar.gz"))
 		if nerr != nil {
 			return status.Errorf(codes.Internal, "cannot create application archive: %s", nerr)
 		}
-		defer appArchive.Close()
-		_, nerr = io.Copy(appArchive, appArchiveData)
-		return nerr
+		defer f.Close()
+		return appArchive.WriteTarGZIP(nctx, f, appArchiveOpts)
 	})
 
 	eg.Go(func() error {
@@ -361,7 +699,7 @@ func generateBuildLocalDir(ctx context.Context, baseDir, dockerfile string, appA
 			return nil
 		}
 
-		return util.ExtractGZIPFileToDir(nctx, files, contextDir)
+		return files.ExtractToDir(nctx, contextDir, b.opts.ContextExtraction)
 	})
 
 	if err = eg.Wait(); err != nil {
@@ -371,53 +709,124 @@ func generateBuildLocalDir(ctx context.Context, baseDir, dockerfile string, appA
 	return rootDir, func() { os.RemoveAll(rootDir) }, nil
 }
 
-func (b *BuildKit) buildFromContainerFile(ctx context.Context, r *pb.BuildRequest, w console.File) (*pb.TsuruConfig, error) {
-	var files io.Reader
-	if len(r.Data) > 0 {
-		files = bytes.NewReader(r.Data)
+// appArchiveCompressOptions returns how application.tar.gz must be written,
+// i.e. reproducibly when the build has a source date epoch.
+func (b *BuildKit) appArchiveCompressOptions(r *pb.BuildRequest) util.CompressOptions {
+	if r.SourceDateEpoch == nil {
+		return util.CompressOptions{}
 	}
 
-	tmpDir, cleanFunc, err := generateBuildLocalDir(ctx, b.opts.TempDir, r.Containerfile, nil, r.App.EnvVars, files)
+	return util.CompressOptions{Reproducible: true, ModTime: r.SourceDateEpoch.AsTime(), TempDir: b.opts.TempDir}
+}
+
+func excludeIgnoredFiles(ctx context.Context, w io.Writer, a *util.Archive, ignoreFile string) (*util.Archive, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	filtered, stats, err := util.ExcludeIgnoredFiles(ctx, a, ignoreFile)
 	if err != nil {
 		return nil, err
 	}
-	defer cleanFunc()
 
-	if err = b.callBuildKitBuild(ctx, tmpDir, r, w); err != nil {
-		return nil, err
+	if stats.Files > 0 {
+		fmt.Fprintf(w, "Excluding %d files (%s) matched by %s\n", stats.Files, units.BytesSize(float64(stats.Size)), ignoreFile)
 	}
 
-	var insecureRegistry bool
-	if r.PushOptions != nil {
-		insecureRegistry = r.PushOptions.InsecureRegistry
+	return filtered, nil
+}
+
+func (b *BuildKit) buildFromContainerFile(ctx context.Context, r *pb.BuildRequest, w console.File) (*pb.TsuruConfig, error) {
+	var files *util.Archive
+	if len(r.Data) > 0 {
+		var err error
+		files, err = util.NewArchive(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		files = files.WithLimits(b.opts.ContextExtraction)
 	}
 
-	ic, err := extractContainerImageConfigFromImageManifest(ctx, r.DestinationImages[0], insecureRegistry)
+	tmpDir, cleanFunc, err := b.generateBuildLocalDir(ctx, w, r.Containerfile, nil, util.CompressOptions{}, b.buildEnvVars(r, w), files)
 	if err != nil {
 		return nil, err
 	}
+	defer cleanFunc()
 
-	tc, err := b.extractTsuruConfigsFromContainerImage(ctx, r.DestinationImages[0], ic.WorkingDir)
+	resp, tc, err := b.callBuildKitBuild(ctx, tmpDir, r, w, progress.NewSummary())
 	if err != nil {
 		return nil, err
 	}
 
-	tc.ImageConfig = ic
+	if err = completeContainerImageConfig(ctx, r, resp, tc.ImageConfig); err != nil {
+		return nil, err
+	}
+
+	deriveProcfileFromImageConfig(tc, w)
+
+	if tc.Attestations, err = b.extractAttestations(ctx, r, resp); err != nil {
+		return nil, err
+	}
 
 	return tc, nil
 }
 
 func (b *BuildKit) buildPlatform(ctx context.Context, r *pb.BuildRequest, w console.File) error {
-	tmpDir, cleanFunc, err := generateBuildLocalDir(ctx, b.opts.TempDir, r.Containerfile, nil, nil, nil)
+	tmpDir, cleanFunc, err := b.generateBuildLocalDir(ctx, w, r.Containerfile, nil, util.CompressOptions{}, nil, nil)
 	if err != nil {
 		return err
 	}
 	defer cleanFunc()
 
-	return b.callBuildKitBuild(ctx, tmpDir, r, w)
+	_, _, err = b.callBuildKitBuild(ctx, tmpDir, r, w, progress.NewSummary())
+	return err
+}
+
+// solveAttrs returns the attributes of both Dockerfile frontend and image
+// exporter.
+func (b *BuildKit) solveAttrs(r *pb.BuildRequest, labels map[string]string, sourceImageDigest string) (map[string]string, map[string]string) {
+	var insecureRegistry bool // disabled by default
+	var pushImage bool = true // enabled by default
+
+	if pots := r.PushOptions; pots != nil {
+		pushImage = !pots.Disable
+		insecureRegistry = pots.InsecureRegistry
+	}
+
+	frontendAttrs := map[string]string{}
+
+	// NOTE: by default, we always run the deploy's script command as user
+	// might need to regenerate assets, for example.
+	if value, ok := build.DeployCacheBust(r, b.buildEnvVars(r, io.Discard), sourceImageDigest, time.Now()); ok {
+		frontendAttrs["build-arg:tsuru_deploy_cache"] = value
+	}
+
+	if epoch := r.SourceDateEpoch; epoch != nil {
+		frontendAttrs["build-arg:SOURCE_DATE_EPOCH"] = strconv.FormatInt(epoch.Seconds, 10)
+	}
+
+	exportAttrs := map[string]string{
+		"name":              strings.Join(r.DestinationImages, ","),
+		"push":              strconv.FormatBool(pushImage),
+		"registry.insecure": strconv.FormatBool(insecureRegistry),
+	}
+
+	for k, v := range labels {
+		frontendAttrs["label:"+k] = v
+		exportAttrs["annotation-manifest."+k] = v
+	}
+
+	for k, v := range b.attestationAttrs(r) {
+		frontendAttrs[k] = v
+	}
+
+	return frontendAttrs, exportAttrs
 }
 
-func (b *BuildKit) callBuildKitBuild(ctx context.Context, buildContextDir string, r *pb.BuildRequest, w console.File) error {
+// callBuildKitBuild builds (and pushes) the container image. On app builds,
+// it also returns the Tsuru config files found in the built image. The build
+// steps are recorded on summary.
+func (b *BuildKit) callBuildKitBuild(ctx context.Context, buildContextDir string, r *pb.BuildRequest, w console.File, summary *progress.Summary) (*client.SolveResponse, *pb.TsuruConfig, error) {
 	var secretSources []secretsprovider.Source
 	if r.App != nil {
 		secretSources = append(secretSources, secretsprovider.Source{
@@ -428,44 +837,41 @@ func (b *BuildKit) callBuildKitBuild(ctx context.Context, buildContextDir string
 
 	secrets, err := secretsprovider.NewStore(secretSources)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	pw, err := progresswriter.NewPrinter(context.Background(), w, "plain") //nolint - using an empty context intentionally
+	sourceImageDigest := resolveSourceImageDigest(ctx, r, w)
+
+	labels, err := b.imageLabels(r, sourceImageDigest)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	eg, nctx := errgroup.WithContext(ctx)
+	pw, err := progress.NewWriter(w, r.ProgressMode)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	eg.Go(func() error {
-		var insecureRegistry bool // disabled by default
-		var pushImage bool = true // enabled by default
+	var resp *client.SolveResponse
+	var tc *pb.TsuruConfig
+	var tsuruYamlWarnings []build.TsuruYamlProblem
 
-		if pots := r.PushOptions; pots != nil {
-			pushImage = !pots.Disable
-			insecureRegistry = pots.InsecureRegistry
-		}
+	eg, nctx := errgroup.WithContext(ctx)
 
+	frontendAttrs, exportAttrs := b.solveAttrs(r, labels, sourceImageDigest)
+
+	eg.Go(func() error {
 		opts := client.SolveOpt{
-			Frontend: "dockerfile.v0",
-			FrontendAttrs: map[string]string{
-				// NOTE: we should always run the deploy's script command as user might
-				// need to regenerate assets, for example.
-				"build-arg:tsuru_deploy_cache": strconv.FormatInt(time.Now().Unix(), 10),
-			},
+			Frontend:      "dockerfile.v0",
+			FrontendAttrs: frontendAttrs,
 			LocalDirs: map[string]string{
 				"context":    filepath.Join(buildContextDir, "context"),
 				"dockerfile": buildContextDir,
 			},
 			Exports: []client.ExportEntry{
 				{
-					Type: client.ExporterImage,
-					Attrs: map[string]string{
-						"name":              strings.Join(r.DestinationImages, ","),
-						"push":              strconv.FormatBool(pushImage),
-						"registry.insecure": strconv.FormatBool(insecureRegistry),
-					},
+					Type:  client.ExporterImage,
+					Attrs: exportAttrs,
 				},
 			},
 			Session: []session.Attachable{
@@ -474,12 +880,31 @@ func (b *BuildKit) callBuildKitBuild(ctx context.Context, buildContextDir string
 			},
 		}
 
-		_, err = b.cli.Build(nctx, opts, "deploy-agent", func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
-			return c.Solve(ctx, gateway.SolveRequest{
+		resp, err = b.cli.Build(nctx, opts, "deploy-agent", func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
+			res, nerr := c.Solve(ctx, gateway.SolveRequest{
 				Frontend:    opts.Frontend,
 				FrontendOpt: opts.FrontendAttrs,
 			})
-		}, progresswriter.ResetTime(pw).Status())
+			if nerr != nil {
+				return nil, nerr
+			}
+
+			if r.App != nil {
+				if tc, nerr = extractTsuruConfigsFromResult(ctx, res, b.tsuruConfigSearch(r)); nerr != nil {
+					return nil, nerr
+				}
+
+				// NOTE: failing here prevents exporting (and pushing) an image
+				// whose tsuru.yaml would be rejected anyway.
+				if validatesTsuruYamlFromImage(r) {
+					if _, tsuruYamlWarnings, nerr = build.ParseTsuruYaml(tc.TsuruYaml); nerr != nil {
+						return nil, nerr
+					}
+				}
+			}
+
+			return res, nil
+		}, progresswriter.ResetTime(progress.Record(pw, summary)).Status())
 		return err
 	})
 
@@ -488,5 +913,95 @@ func (b *BuildKit) callBuildKitBuild(ctx context.Context, buildContextDir string
 		return pw.Err()
 	})
 
-	return eg.Wait()
+	err = eg.Wait()
+
+	// NOTE: rawjson clients get the step durations from the solve statuses
+	// already, so the summary is left out.
+	if r.ProgressMode != pb.ProgressMode_PROGRESS_MODE_RAWJSON {
+		summary.Print(w)
+	}
+
+	printTsuruYamlWarnings(w, tsuruYamlWarnings)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = b.signContainerImages(ctx, r, resp, w); err != nil {
+		return nil, nil, err
+	}
+
+	return resp, tc, nil
+}
+
+func (b *BuildKit) signContainerImages(ctx context.Context, r *pb.BuildRequest, resp *client.SolveResponse, w io.Writer) error {
+	if b.opts.Signer == nil {
+		return nil
+	}
+
+	var insecureRegistry bool
+	if pots := r.PushOptions; pots != nil {
+		if pots.Disable { // there's nothing to sign
+			return nil
+		}
+
+		insecureRegistry = pots.InsecureRegistry
+	}
+
+	var digest string
+	if resp != nil {
+		digest = resp.ExporterResponse[exptypes.ExporterImageDigestKey]
+	}
+
+	if digest == "" {
+		return status.Error(codes.Internal, "failed to sign container image: image digest not found in the build response")
+	}
+
+	signed := make(map[string]bool)
+	for _, image := range r.DestinationImages {
+		ref, remoteOpts, err := containerRegistryReference(ctx, image, insecureRegistry)
+		if err != nil {
+			return err
+		}
+
+		d := ref.Context().Digest(digest)
+		if signed[d.String()] { // same repository, different tags
+			continue
+		}
+
+		tag, err := b.opts.Signer.SignImage(ctx, d, remoteOpts...)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to sign container image %s: %s", d, err)
+		}
+
+		signed[d.String()] = true
+		fmt.Fprintf(w, "Container image %s signed, signature pushed to %s\n", d, tag)
+	}
+
+	return nil
+}
+
+// resolveSourceImageDigest resolves the digest of the source image, if any. It's
+// not critical, so failures are only reported in the output.
+func resolveSourceImageDigest(ctx context.Context, r *pb.BuildRequest, w io.Writer) string {
+	if r.SourceImage == "" {
+		return ""
+	}
+
+	var insecureRegistry bool
+	if r.PushOptions != nil {
+		insecureRegistry = r.PushOptions.InsecureRegistry
+	}
+
+	digest, err := resolveContainerImageDigest(ctx, r.SourceImage, insecureRegistry)
+	if err != nil {
+		fmt.Fprintf(w, "Could not resolve the digest of source image %s: %s\n", r.SourceImage, err)
+		return ""
+	}
+
+	return digest
+}
+
+func (b *BuildKit) imageLabels(r *pb.BuildRequest, sourceImageDigest string) (map[string]string, error) {
+	return build.ImageLabels(build.NewImageMetadata(r, sourceImageDigest, time.Now()), b.opts.ImageLabels)
 }
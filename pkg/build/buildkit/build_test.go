@@ -7,6 +7,9 @@ package buildkit_test
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -14,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	dockertypes "github.com/docker/docker/api/types"
 	dockertypescontainer "github.com/docker/docker/api/types/container"
@@ -23,9 +27,11 @@ import (
 	"github.com/moby/buildkit/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	. "github.com/tsuru/deploy-agent/pkg/build/buildkit"
 	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+	"github.com/tsuru/deploy-agent/pkg/signature"
 	"github.com/tsuru/deploy-agent/pkg/util"
 )
 
@@ -100,8 +106,10 @@ func TestBuildKit_Build_FromSourceFiles(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Equal(t, &pb.TsuruConfig{
-		Procfile:  "web: python app.py\n",
-		TsuruYaml: "hooks:\n  build:\n  - touch /tmp/foo\n  - |-\n    mkdir -p /tmp/tsuru \\\n    && echo \"MY_ENV_VAR=${MY_ENV_VAR}\" > /tmp/tsuru/envs \\\n    && echo \"DATABASE_PASSWORD=${DATABASE_PASSWORD}\" >> /tmp/tsuru/envs\n  - python --version\n\nhealthcheck:\n  path: /\n",
+		Procfile:      "web: python app.py\n",
+		ProcfilePath:  "/home/application/current/Procfile",
+		TsuruYamlPath: "/home/application/current/tsuru.yml",
+		TsuruYaml:     "hooks:\n  build:\n  - touch /tmp/foo\n  - |-\n    mkdir -p /tmp/tsuru \\\n    && echo \"MY_ENV_VAR=${MY_ENV_VAR}\" > /tmp/tsuru/envs \\\n    && echo \"DATABASE_PASSWORD=${DATABASE_PASSWORD}\" >> /tmp/tsuru/envs\n  - python --version\n\nhealthcheck:\n  path: /\n",
 	}, appFiles)
 
 	dc := newDockerClient(t)
@@ -291,7 +299,8 @@ func TestBuildKit_Build_AppDeployFromSourceFiles_NoUserDefinedProcfile(t *testin
 
 	require.NoError(t, err)
 	assert.Equal(t, &pb.TsuruConfig{
-		Procfile: "web: /usr/sbin/nginx -g \"daemon off;\"\n",
+		Procfile:     "web: /usr/sbin/nginx -g \"daemon off;\"\n",
+		ProcfilePath: "/home/application/current/Procfile",
 	}, appFiles)
 }
 
@@ -343,9 +352,12 @@ func TestBuildKit_Build_FromContainerImages(t *testing.T) {
 			Build(context.TODO(), req, os.Stdout)
 
 		require.NoError(t, err)
+		cleanContainerImageConfig(t, appFiles)
 		assert.Equal(t, &pb.TsuruConfig{
-			Procfile:  "web: my-server --addr 0.0.0.0:${PORT}\nworker: ./path/to/worker.sh --debug\n",
-			TsuruYaml: "healthcheck:\n  path: /healthz\n  interval_seconds: 3\n  timeout_seconds: 1\n",
+			Procfile:      "web: my-server --addr 0.0.0.0:${PORT}\nworker: ./path/to/worker.sh --debug\n",
+			ProcfilePath:  "/home/application/current/Procfile",
+			TsuruYamlPath: "/home/application/current/tsuru.yaml",
+			TsuruYaml:     "healthcheck:\n  path: /healthz\n  interval_seconds: 3\n  timeout_seconds: 1\n",
 			ImageConfig: &pb.ContainerImageConfig{
 				Cmd: []string{"sh"},
 			},
@@ -367,11 +379,16 @@ func TestBuildKit_Build_FromContainerImages(t *testing.T) {
 			Build(context.TODO(), req, os.Stdout)
 
 		require.NoError(t, err)
+		cleanContainerImageConfig(t, appFiles)
 		assert.Equal(t, &pb.TsuruConfig{
+			Procfile:        "web: /docker-entrypoint.sh nginx -g 'daemon off;'\n",
+			ProcfileDerived: true,
 			ImageConfig: &pb.ContainerImageConfig{
 				Entrypoint:   []string{"/docker-entrypoint.sh"},
 				Cmd:          []string{"nginx", "-g", "daemon off;"},
 				ExposedPorts: []string{"80/tcp"},
+				Ports:        []*pb.ContainerImagePort{{Port: 80, Protocol: "tcp"}},
+				StopSignal:   "SIGQUIT",
 			},
 		}, appFiles)
 	})
@@ -402,8 +419,11 @@ func TestBuildKit_Build_FromContainerFile(t *testing.T) {
 
 		appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
 		require.NoError(t, err)
+		cleanContainerImageConfig(t, appFiles)
 		assert.Equal(t, &pb.TsuruConfig{
-			Procfile: "web: /path/to/webserver.sh --port 8888\nworker: /path/to/worker.sh\n",
+			Procfile:      "web: /path/to/webserver.sh --port 8888\nworker: /path/to/worker.sh\n",
+			ProcfilePath:  "/app/user/Procfile",
+			TsuruYamlPath: "/app/user/tsuru.yaml",
 			TsuruYaml: `healthcheck:
   command:
   - /usr/bin/true
@@ -463,7 +483,12 @@ ENV MY_ANOTHER_VAR="another var"
 
 		appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
 		require.NoError(t, err)
+		require.NotNil(t, appFiles.ImageConfig)
+		assert.Contains(t, appFiles.ImageConfig.Env, "MY_ANOTHER_VAR=another var")
+		cleanContainerImageConfig(t, appFiles)
 		assert.Equal(t, &pb.TsuruConfig{
+			Procfile:        "web: sh\n",
+			ProcfileDerived: true,
 			ImageConfig: &pb.ContainerImageConfig{
 				Cmd: []string{"sh"},
 			},
@@ -571,15 +596,56 @@ CMD ["--port", "8080"]
 
 		appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
 		require.NoError(t, err)
+		cleanContainerImageConfig(t, appFiles)
 		assert.Equal(t, &pb.TsuruConfig{
+			Procfile:        "web: /path/to/my/server.sh --port 8080\n",
+			ProcfileDerived: true,
 			ImageConfig: &pb.ContainerImageConfig{
 				Entrypoint:   []string{"/path/to/my/server.sh"},
 				Cmd:          []string{"--port", "8080"},
 				ExposedPorts: []string{"8080/tcp"},
+				Ports:        []*pb.ContainerImagePort{{Port: 8080, Protocol: "tcp"}},
 			},
 		}, appFiles)
 	})
 
+	t.Run("push disabled, should get config from the build itself", func(t *testing.T) {
+		destImage := baseRegistry(t, "my-app", "")
+
+		dockerfile := `FROM busybox
+
+RUN set -xef \
+    && mkdir -p /srv/app \
+    && echo "web: /srv/app/server.sh" > /srv/app/Procfile
+
+WORKDIR /srv/app
+
+EXPOSE 8888/tcp
+`
+
+		req := &pb.BuildRequest{
+			Kind: pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_CONTAINER_FILE,
+			App: &pb.TsuruApp{
+				Name: "my-app",
+			},
+			DestinationImages: []string{destImage},
+			Containerfile:     dockerfile,
+			PushOptions: &pb.PushOptions{
+				Disable: true,
+			},
+		}
+
+		appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
+		require.NoError(t, err)
+		require.NotNil(t, appFiles.ImageConfig)
+		assert.Regexp(t, `^sha256:[a-f0-9]{64}$`, appFiles.ImageConfig.Digest)
+		assert.Zero(t, appFiles.ImageConfig.Size)
+		assert.Equal(t, "web: /srv/app/server.sh\n", appFiles.Procfile)
+		assert.Equal(t, "/srv/app/Procfile", appFiles.ProcfilePath)
+		assert.Equal(t, "/srv/app", appFiles.ImageConfig.WorkingDir)
+		assert.Equal(t, []string{"8888/tcp"}, appFiles.ImageConfig.ExposedPorts)
+	})
+
 	t.Run("using a different working directory, should get Procfile and Tsuru YAML from that", func(t *testing.T) {
 		destImage := baseRegistry(t, "my-app", "")
 
@@ -609,22 +675,136 @@ EXPOSE 8888/tcp
 
 		appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
 		require.NoError(t, err)
+		cleanContainerImageConfig(t, appFiles)
 		assert.Equal(t, &pb.TsuruConfig{
-			Procfile:  "web: /path/to/server.sh --port 8888\n",
-			TsuruYaml: "healthcheck:\n  path: /healthz\n\n",
+			Procfile:      "web: /path/to/server.sh --port 8888\n",
+			ProcfilePath:  "/var/my-app/Procfile",
+			TsuruYamlPath: "/var/my-app/tsuru.yaml",
+			TsuruYaml:     "healthcheck:\n  path: /healthz\n\n",
 			ImageConfig: &pb.ContainerImageConfig{
 				Cmd:          []string{"sh"},
 				ExposedPorts: []string{"8888/tcp"},
+				Ports:        []*pb.ContainerImagePort{{Port: 8888, Protocol: "tcp"}},
 				WorkingDir:   "/var/my-app",
 			},
 		}, appFiles)
 	})
 }
 
+func TestBuildKit_Build_Reproducible(t *testing.T) {
+	bc := newBuildKitClient(t)
+	defer bc.Close()
+
+	req := &pb.BuildRequest{
+		Kind: pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_CONTAINER_FILE,
+		App: &pb.TsuruApp{
+			Name: "my-app",
+		},
+		DestinationImages: []string{baseRegistry(t, "my-app", "")},
+		Containerfile:     "FROM busybox\nCOPY . /app/user/\n",
+		Data:              compressGZIP(t, "./testdata/container_file/"),
+		SourceDateEpoch:   &timestamppb.Timestamp{Seconds: 1700000000},
+		PushOptions: &pb.PushOptions{
+			InsecureRegistry: registryHTTP,
+		},
+	}
+
+	var digests []string
+	for i := 0; i < 2; i++ {
+		appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
+		require.NoError(t, err)
+		require.NotNil(t, appFiles.ImageConfig)
+		digests = append(digests, appFiles.ImageConfig.Digest)
+
+		time.Sleep(time.Second) // so the wall clock would change the build time
+	}
+
+	assert.Equal(t, digests[0], digests[1])
+}
+
+func TestBuildKit_Build_WithAttestations(t *testing.T) {
+	bc := newBuildKitClient(t)
+	defer bc.Close()
+
+	req := &pb.BuildRequest{
+		Kind: pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_CONTAINER_FILE,
+		App: &pb.TsuruApp{
+			Name: "my-app",
+		},
+		DestinationImages: []string{baseRegistry(t, "my-app", "")},
+		Containerfile:     "FROM busybox\n",
+		Attestations: &pb.AttestationOptions{
+			Provenance: pb.ProvenanceMode_PROVENANCE_MODE_MIN,
+		},
+		PushOptions: &pb.PushOptions{
+			InsecureRegistry: registryHTTP,
+		},
+	}
+
+	appFiles, err := NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir()}).Build(context.TODO(), req, os.Stdout)
+	require.NoError(t, err)
+	require.Len(t, appFiles.Attestations, 1)
+	assert.Regexp(t, `^sha256:[a-f0-9]{64}$`, appFiles.Attestations[0].Digest)
+	assert.Regexp(t, `^sha256:[a-f0-9]{64}$`, appFiles.Attestations[0].SubjectDigest)
+	assert.Equal(t, []string{"https://slsa.dev/provenance/v0.2"}, appFiles.Attestations[0].PredicateTypes)
+	assert.NotZero(t, appFiles.ImageConfig.Size)
+}
+
+func TestBuildKit_Build_SigningImages(t *testing.T) {
+	bc := newBuildKitClient(t)
+	defer bc.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := signature.NewSigner(key)
+	require.NoError(t, err)
+
+	destImage := baseRegistry(t, "my-app", "")
+
+	req := &pb.BuildRequest{
+		Kind: pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_CONTAINER_FILE,
+		App: &pb.TsuruApp{
+			Name: "my-app",
+		},
+		DestinationImages: []string{destImage},
+		Containerfile:     "FROM busybox\n",
+		PushOptions: &pb.PushOptions{
+			InsecureRegistry: registryHTTP,
+		},
+	}
+
+	var output bytes.Buffer
+	_, err = NewBuildKit(bc, BuildKitOptions{TempDir: t.TempDir(), Signer: signer}).Build(context.TODO(), req, &fakeConsoleFile{Writer: io.MultiWriter(&output, os.Stdout)})
+	require.NoError(t, err)
+	assert.Regexp(t, `Container image (.+)@sha256:[a-f0-9]{64} signed, signature pushed to (.+):sha256-[a-f0-9]{64}\.sig`, output.String())
+}
+
+type fakeConsoleFile struct {
+	io.Writer
+}
+
+func (f *fakeConsoleFile) Read(p []byte) (int, error) { return 0, nil }
+func (f *fakeConsoleFile) Close() error               { return nil }
+func (f *fakeConsoleFile) Fd() uintptr                { return uintptr(0) }
+func (f *fakeConsoleFile) Name() string               { return "" }
+
+// cleanContainerImageConfig checks and then erases the fields of the image
+// config which vary between builds (or base image updates).
+func cleanContainerImageConfig(t *testing.T, tc *pb.TsuruConfig) {
+	t.Helper()
+	require.NotNil(t, tc)
+	require.NotNil(t, tc.ImageConfig)
+	assert.Regexp(t, `^sha256:[a-f0-9]{64}$`, tc.ImageConfig.Digest)
+	assert.NotZero(t, tc.ImageConfig.Size)
+	assert.Equal(t, "my-app", tc.ImageConfig.Labels["io.tsuru.app.name"])
+	tc.ImageConfig.Digest, tc.ImageConfig.Size, tc.ImageConfig.Env, tc.ImageConfig.Labels = "", 0, nil, nil
+}
+
 func compressGZIP(t *testing.T, path string) []byte {
 	t.Helper()
 	var data bytes.Buffer
-	require.NoError(t, util.CompressGZIPFile(context.TODO(), &data, path))
+	require.NoError(t, util.CompressGZIPFile(context.TODO(), &data, path, util.CompressOptions{}))
 	return data.Bytes()
 }
 
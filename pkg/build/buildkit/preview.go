@@ -0,0 +1,111 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildkit
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+	"github.com/tsuru/deploy-agent/pkg/util"
+)
+
+// Preview goes through the same steps of Build up to the solve, i.e. it may
+// download the app source data and look up the source image on registry, but
+// it neither calls BuildKit nor pushes anything.
+func (b *BuildKit) Preview(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	w := io.Discard // messages are only meaningful along with the build output
+
+	var preview *pb.BuildPreview
+	var err error
+
+	switch pb.BuildKind_name[int32(r.Kind)] {
+	case "BUILD_KIND_APP_BUILD_WITH_SOURCE_UPLOAD":
+		preview, err = b.previewFromAppSourceFiles(ctx, r, w)
+
+	case "BUILD_KIND_APP_BUILD_WITH_CONTAINER_IMAGE":
+		preview = &pb.BuildPreview{Containerfile: fmt.Sprintf("FROM %s", r.SourceImage)}
+
+	case "BUILD_KIND_APP_BUILD_WITH_CONTAINER_FILE":
+		preview, err = b.previewFromContainerFile(ctx, r)
+
+	case "BUILD_KIND_PLATFORM_WITH_CONTAINER_FILE":
+		preview = &pb.BuildPreview{Containerfile: r.Containerfile}
+
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "build kind not supported")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sourceImageDigest := resolveSourceImageDigest(ctx, r, w)
+
+	labels, err := b.imageLabels(r, sourceImageDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	preview.FrontendAttrs, _ = b.solveAttrs(r, labels, sourceImageDigest)
+
+	return preview, nil
+}
+
+func (b *BuildKit) previewFromAppSourceFiles(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.BuildPreview, error) {
+	asb, err := b.prepareAppSourceBuild(ctx, r, w)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := asb.archive.Files(ctx) // already filtered by .tsuruignore
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.BuildPreview{
+		Containerfile: asb.containerfile,
+		TsuruConfig:   asb.appFiles,
+		BuildHooks:    asb.params.BuildHooks,
+		ContextFiles:  files,
+	}, nil
+}
+
+func (b *BuildKit) previewFromContainerFile(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error) {
+	preview := &pb.BuildPreview{Containerfile: r.Containerfile}
+	if len(r.Data) == 0 {
+		return preview, nil
+	}
+
+	a, err := util.NewArchive(r.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if preview.ContextFiles, err = contextFiles(ctx, a.WithLimits(b.opts.ContextExtraction), dockerIgnoreFile); err != nil {
+		return nil, err
+	}
+
+	return preview, nil
+}
+
+// contextFiles lists the files of archive which are sent to the build, i.e.
+// the ones not matched by the ignore file.
+func contextFiles(ctx context.Context, a *util.Archive, ignoreFile string) ([]string, error) {
+	filtered, _, err := util.ExcludeIgnoredFiles(ctx, a, ignoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return filtered.Files(ctx)
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package progress prints BuildKit's solve status in the build output.
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/containerd/console"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/progress/progresswriter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+// maxLogSize is the max size in bytes of logs held per step on condensed
+// mode, older logs are dropped.
+const maxLogSize = 64 << 10 // 64 KiB
+
+// NewWriter returns a progress writer which prints on w in the given mode.
+func NewWriter(w console.File, mode pb.ProgressMode) (progresswriter.Writer, error) {
+	switch mode {
+	case pb.ProgressMode_PROGRESS_MODE_UNSPECIFIED, pb.ProgressMode_PROGRESS_MODE_PLAIN:
+		return progresswriter.NewPrinter(context.Background(), w, "plain") //nolint - using an empty context intentionally
+
+	case pb.ProgressMode_PROGRESS_MODE_TTY:
+		c := &condensed{w: w, steps: make(map[string]*condensedStep)}
+		return newPrinter(c.print), nil
+
+	case pb.ProgressMode_PROGRESS_MODE_RAWJSON:
+		enc := json.NewEncoder(w)
+		return newPrinter(func(st *client.SolveStatus) error { return enc.Encode(st) }), nil
+	}
+
+	return nil, status.Errorf(codes.InvalidArgument, "invalid progress mode %s", mode)
+}
+
+type printer struct {
+	status chan *client.SolveStatus
+	done   chan struct{}
+	err    error
+}
+
+// newPrinter calls print for every solve status. Once print fails, the
+// remaining statuses are discarded, so the solve isn't blocked.
+func newPrinter(print func(*client.SolveStatus) error) *printer {
+	p := &printer{status: make(chan *client.SolveStatus), done: make(chan struct{})}
+
+	go func() {
+		defer close(p.done)
+
+		for st := range p.status {
+			if p.err == nil {
+				p.err = print(st)
+			}
+		}
+	}()
+
+	return p
+}
+
+func (p *printer) Done() <-chan struct{} { return p.done }
+
+func (p *printer) Err() error { return p.err }
+
+func (p *printer) Status() chan *client.SolveStatus { return p.status }
+
+// condensed prints like BuildKit's tty mode, without redrawing the screen:
+// one line per finished step, followed by its logs only when it fails.
+type condensed struct {
+	w     io.Writer
+	steps map[string]*condensedStep
+}
+
+type condensedStep struct {
+	logs    []byte
+	printed bool
+}
+
+func (c *condensed) print(st *client.SolveStatus) error {
+	for _, l := range st.Logs {
+		s := c.step(l.Vertex.String())
+		if s.printed {
+			continue
+		}
+
+		s.logs = append(s.logs, l.Data...)
+		if len(s.logs) > maxLogSize {
+			s.logs = s.logs[len(s.logs)-maxLogSize:]
+		}
+	}
+
+	for _, v := range st.Vertexes {
+		s := c.step(v.Digest.String())
+		if s.printed || v.Completed == nil || v.Name == "" {
+			continue
+		}
+
+		s.printed = true
+
+		if v.Cached {
+			fmt.Fprintf(c.w, " => CACHED %s\n", stepName(v.Name))
+			continue
+		}
+
+		var d time.Duration
+		if v.Started != nil {
+			d = v.Completed.Sub(*v.Started)
+		}
+
+		if v.Error == "" {
+			fmt.Fprintf(c.w, " => %s %s\n", stepName(v.Name), formatDuration(d))
+			continue
+		}
+
+		fmt.Fprintf(c.w, " => ERROR %s %s\n------\n > %s:\n", stepName(v.Name), formatDuration(d), stepName(v.Name))
+		c.w.Write(s.logs)
+		if len(s.logs) > 0 && s.logs[len(s.logs)-1] != '\n' {
+			fmt.Fprintln(c.w)
+		}
+		fmt.Fprintln(c.w, "------")
+
+		s.logs = nil
+	}
+
+	return nil
+}
+
+func (c *condensed) step(digest string) *condensedStep {
+	s, found := c.steps[digest]
+	if !found {
+		s = &condensedStep{}
+		c.steps[digest] = s
+	}
+
+	return s
+}
+
+// stepName returns the vertex name in a single line.
+func stepName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package progress_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/progress/progresswriter"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tsuru/deploy-agent/pkg/build/buildkit/progress"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+var (
+	started = time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	copyStep = digest.FromString("copy")
+	runStep  = digest.FromString("run")
+	hookStep = digest.FromString("hook")
+)
+
+// solveStatuses simulates a build whose last step (build hook) fails.
+func solveStatuses() []*client.SolveStatus {
+	at := func(d time.Duration) *time.Time {
+		t := started.Add(d)
+		return &t
+	}
+
+	return []*client.SolveStatus{
+		{Vertexes: []*client.Vertex{
+			{Digest: copyStep, Name: "[2/4] COPY ./application.tar.gz /home/application/archive.tar.gz", Started: at(0), Completed: at(0), Cached: true},
+			{Digest: runStep, Name: "[3/4] RUN /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz", Started: at(time.Second)},
+		}},
+		{Logs: []*client.VertexLog{{Vertex: runStep, Data: []byte("Installing dependencies...\n")}}},
+		{Vertexes: []*client.Vertex{
+			{Digest: runStep, Name: "[3/4] RUN /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz", Started: at(time.Second), Completed: at(31500 * time.Millisecond)},
+			{Digest: hookStep, Name: "[4/4] RUN tsuru_build_hook=0 sh -lc 'make assets'", Started: at(32 * time.Second)},
+		}},
+		{Logs: []*client.VertexLog{{Vertex: hookStep, Data: []byte("make: *** No rule to make target 'assets'.  Stop.")}}},
+		{Vertexes: []*client.Vertex{
+			{Digest: hookStep, Name: "[4/4] RUN tsuru_build_hook=0 sh -lc 'make assets'", Started: at(32 * time.Second), Completed: at(32300 * time.Millisecond), Error: "exit code: 2"},
+		}},
+	}
+}
+
+func writeStatuses(t *testing.T, pw progresswriter.Writer, statuses []*client.SolveStatus) {
+	t.Helper()
+
+	for _, st := range statuses {
+		pw.Status() <- st
+	}
+
+	close(pw.Status())
+	<-pw.Done()
+	require.NoError(t, pw.Err())
+}
+
+func TestNewWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tty", func(t *testing.T) {
+		t.Parallel()
+
+		var out fakeConsoleFile
+		pw, err := NewWriter(&out, pb.ProgressMode_PROGRESS_MODE_TTY)
+		require.NoError(t, err)
+
+		writeStatuses(t, pw, solveStatuses())
+
+		assert.Equal(t, ` => CACHED [2/4] COPY ./application.tar.gz /home/application/archive.tar.gz
+ => [3/4] RUN /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz 30.5s
+ => ERROR [4/4] RUN tsuru_build_hook=0 sh -lc 'make assets' 0.3s
+------
+ > [4/4] RUN tsuru_build_hook=0 sh -lc 'make assets':
+make: *** No rule to make target 'assets'.  Stop.
+------
+`, out.String())
+	})
+
+	t.Run("rawjson", func(t *testing.T) {
+		t.Parallel()
+
+		var out fakeConsoleFile
+		pw, err := NewWriter(&out, pb.ProgressMode_PROGRESS_MODE_RAWJSON)
+		require.NoError(t, err)
+
+		writeStatuses(t, pw, solveStatuses())
+
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		require.Len(t, lines, 5)
+
+		var st client.SolveStatus
+		require.NoError(t, json.Unmarshal([]byte(lines[4]), &st))
+		require.Len(t, st.Vertexes, 1)
+		assert.Equal(t, hookStep, st.Vertexes[0].Digest)
+		assert.Equal(t, "exit code: 2", st.Vertexes[0].Error)
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		t.Parallel()
+
+		var out fakeConsoleFile
+		pw, err := NewWriter(&out, pb.ProgressMode_PROGRESS_MODE_UNSPECIFIED)
+		require.NoError(t, err)
+
+		writeStatuses(t, pw, solveStatuses())
+
+		assert.Contains(t, out.String(), "#1 [2/4] COPY ./application.tar.gz /home/application/archive.tar.gz\n#1 CACHED\n")
+		assert.Contains(t, out.String(), "Installing dependencies...\n")
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewWriter(&fakeConsoleFile{}, pb.ProgressMode(42))
+		assert.EqualError(t, err, "rpc error: code = InvalidArgument desc = invalid progress mode 42")
+	})
+}
+
+type fakeConsoleFile struct {
+	bytes.Buffer
+}
+
+func (f *fakeConsoleFile) Close() error { return nil }
+func (f *fakeConsoleFile) Fd() uintptr  { return uintptr(0) }
+func (f *fakeConsoleFile) Name() string { return "" }
@@ -0,0 +1,186 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/progress/progresswriter"
+)
+
+// maxStepNameLength is the max length of step names in the summary, longer
+// ones (e.g. RUN with long commands) are truncated.
+const maxStepNameLength = 72
+
+// Summary holds the duration and cache hit of every build step.
+type Summary struct {
+	steps map[string]*summaryStep
+	order int
+	mu    sync.Mutex
+}
+
+type summaryStep struct {
+	name      string
+	started   *time.Time
+	completed *time.Time
+	errored   bool
+	cached    bool
+	order     int
+}
+
+func NewSummary() *Summary {
+	return &Summary{steps: make(map[string]*summaryStep)}
+}
+
+// Record returns a progress writer which updates s with every solve status
+// before passing it to w.
+func Record(w progresswriter.Writer, s *Summary) progresswriter.Writer {
+	r := &recorder{Writer: w, status: make(chan *client.SolveStatus)}
+
+	go func() {
+		for st := range r.status {
+			s.Update(st)
+			w.Status() <- st
+		}
+
+		close(w.Status())
+	}()
+
+	return r
+}
+
+type recorder struct {
+	progresswriter.Writer
+	status chan *client.SolveStatus
+}
+
+func (r *recorder) Status() chan *client.SolveStatus { return r.status }
+
+func (s *Summary) Update(st *client.SolveStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range st.Vertexes {
+		if v.Name == "" {
+			continue
+		}
+
+		step, found := s.steps[v.Digest.String()]
+		if !found {
+			s.order++
+			step = &summaryStep{order: s.order}
+			s.steps[v.Digest.String()] = step
+		}
+
+		step.name = stepName(v.Name)
+		step.cached = v.Cached
+		step.errored = v.Error != ""
+
+		if v.Started != nil {
+			step.started = v.Started
+		}
+
+		if v.Completed != nil {
+			step.completed = v.Completed
+		}
+	}
+}
+
+// Duration returns how long the first completed step whose name contains
+// substr ran, or zero when there's no such step.
+func (s *Summary) Duration(substr string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, step := range s.sortedSteps() {
+		if step.completed != nil && strings.Contains(step.name, substr) {
+			return step.completed.Sub(*step.started)
+		}
+	}
+
+	return 0
+}
+
+// Print writes the steps (in the order they started) as a table, followed by
+// the total duration and number of cache hits.
+func (s *Summary) Print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	steps := s.sortedSteps()
+	if len(steps) == 0 {
+		return
+	}
+
+	var cached int
+	first, last := *steps[0].started, *steps[0].started
+
+	fmt.Fprintln(w, "---> Build steps summary")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDURATION\tSTATUS")
+
+	for _, step := range steps {
+		duration, status := "-", "CANCELED"
+		if step.completed != nil {
+			duration, status = formatDuration(step.completed.Sub(*step.started)), "DONE"
+
+			if step.completed.After(last) {
+				last = *step.completed
+			}
+		}
+
+		switch {
+		case step.cached:
+			status = "CACHED"
+			cached++
+
+		case step.errored:
+			status = "ERROR"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", truncate(step.name, maxStepNameLength), duration, status)
+	}
+
+	tw.Flush()
+
+	fmt.Fprintf(w, "Total: %s, %d of %d steps cached\n", formatDuration(last.Sub(first)), cached, len(steps))
+}
+
+// sortedSteps returns the started steps in the order they started.
+func (s *Summary) sortedSteps() []*summaryStep {
+	var steps []*summaryStep
+	for _, step := range s.steps {
+		if step.started != nil {
+			steps = append(steps, step)
+		}
+	}
+
+	sort.Slice(steps, func(i, j int) bool {
+		if !steps[i].started.Equal(*steps[j].started) {
+			return steps[i].started.Before(*steps[j].started)
+		}
+
+		return steps[i].order < steps[j].order
+	})
+
+	return steps
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n-3]) + "..."
+}
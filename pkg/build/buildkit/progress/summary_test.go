@@ -0,0 +1,77 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package progress_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tsuru/deploy-agent/pkg/build/buildkit/progress"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestSummary(t *testing.T) {
+	t.Parallel()
+
+	var out fakeConsoleFile
+	pw, err := NewWriter(&out, pb.ProgressMode_PROGRESS_MODE_TTY)
+	require.NoError(t, err)
+
+	s := NewSummary()
+	writeStatuses(t, Record(pw, s), solveStatuses())
+
+	assert.Contains(t, out.String(), " => CACHED [2/4] COPY")
+
+	var b bytes.Buffer
+	s.Print(&b)
+	assert.Equal(t, `---> Build steps summary
+STEP                                                                      DURATION  STATUS
+[2/4] COPY ./application.tar.gz /home/application/archive.tar.gz          0.0s      CACHED
+[3/4] RUN /var/lib/tsuru/deploy archive file:///home/application/arch...  30.5s     DONE
+[4/4] RUN tsuru_build_hook=0 sh -lc 'make assets'                         0.3s      ERROR
+Total: 32.3s, 1 of 3 steps cached
+`, b.String())
+}
+
+func TestSummary_Duration(t *testing.T) {
+	t.Parallel()
+
+	var out fakeConsoleFile
+	pw, err := NewWriter(&out, pb.ProgressMode_PROGRESS_MODE_PLAIN)
+	require.NoError(t, err)
+
+	s := NewSummary()
+	writeStatuses(t, Record(pw, s), solveStatuses())
+
+	assert.Equal(t, 300*time.Millisecond, s.Duration("tsuru_build_hook=0 "))
+	assert.Equal(t, time.Duration(0), s.Duration("tsuru_build_hook=1 "))
+}
+
+func TestSummary_Canceled(t *testing.T) {
+	t.Parallel()
+
+	s := NewSummary()
+	s.Update(&client.SolveStatus{Vertexes: []*client.Vertex{
+		{Digest: runStep, Name: "[3/4] RUN make", Started: &started},
+		{Digest: hookStep, Name: "[4/4] RUN make assets"}, // never started
+	}})
+
+	var b bytes.Buffer
+	s.Print(&b)
+	assert.Equal(t, `---> Build steps summary
+STEP            DURATION  STATUS
+[3/4] RUN make  -         CANCELED
+Total: 0.0s, 0 of 1 steps cached
+`, b.String())
+
+	b.Reset()
+	NewSummary().Print(&b)
+	assert.Empty(t, b.String())
+}
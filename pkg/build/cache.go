@@ -0,0 +1,126 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+// DeployCacheBust returns the value of tsuru_deploy_cache build arg, which
+// busts the layer cache of deploy's script step whenever it changes. It
+// returns false when the build arg must be left out, keeping it fixed. Envs
+// are the env vars visible at build time (see BuildEnvVars).
+func DeployCacheBust(r *pb.BuildRequest, envs map[string]string, sourceImageDigest string, now time.Time) (string, bool) {
+	switch r.CachePolicy {
+	case pb.CachePolicy_CACHE_POLICY_NEVER:
+		return "", false
+
+	case pb.CachePolicy_CACHE_POLICY_CONTENT:
+		return deployContentHash(r, envs, sourceImageDigest), true
+	}
+
+	// NOTE: the source date epoch must not be the cache key on its own: env
+	// vars reach deploy's step through a secret mount, which doesn't key the
+	// cache, so changed env vars would reuse a stale layer.
+
+	return strconv.FormatInt(now.Unix(), 10), true
+}
+
+// deployContentHash returns the SHA-256 of everything that affects the
+// deploy's script output: app source data, build env vars and source image.
+// When the source image digest is unknown, its name is used instead.
+func deployContentHash(r *pb.BuildRequest, envs map[string]string, sourceImageDigest string) string {
+	h := sha256.New()
+
+	if ra := r.RemoteArchive; ra != nil {
+		fmt.Fprintf(h, "remote-archive:%s\x00", ra.Sha256)
+	} else {
+		data := sha256.Sum256(r.Data)
+		fmt.Fprintf(h, "data:%x\x00", data)
+	}
+
+	for _, k := range sortedKeys(envs) {
+		fmt.Fprintf(h, "env:%s=%s\x00", k, envs[k])
+	}
+
+	source := sourceImageDigest
+	if source == "" {
+		source = r.SourceImage
+	}
+
+	io.WriteString(h, "source-image:"+source)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheDirsLabel is the label of platform's container image which lists the
+// dirs (comma-separated absolute paths) kept across deploys of the same app,
+// e.g. package manager caches as /home/application/.cache/pip.
+const CacheDirsLabel = "io.tsuru.cache-dirs"
+
+var cacheDirRegexp = regexp.MustCompile(`^/[\w./-]+$`)
+
+// ParseCacheDirs returns the valid cache dirs in the CacheDirsLabel's value,
+// cleaned and sorted. Invalid dirs don't stop the parsing, instead they're
+// returned as problems.
+func ParseCacheDirs(value string) ([]string, []string) {
+	var dirs, problems []string
+
+	seen := make(map[string]bool)
+
+	for _, dir := range strings.Split(value, ",") {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+
+		if !cacheDirRegexp.MatchString(dir) || path.Clean(dir) == "/" {
+			problems = append(problems, fmt.Sprintf("cache dir %q must be an absolute path (other than /) with only letters, digits and . _ - / characters", dir))
+			continue
+		}
+
+		dir = path.Clean(dir)
+		if seen[dir] {
+			continue
+		}
+
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+	return dirs, problems
+}
+
+var appNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+
+func validateAppName(app *pb.TsuruApp) error {
+	if name := app.GetName(); name != "" && !appNameRegexp.MatchString(name) {
+		return fmt.Errorf("app name %q must have only lowercase letters, digits and - characters (up to 63), starting with a letter", name)
+	}
+
+	return nil
+}
+
+// CacheMountID returns the ID of app's cache mount of dir. It's the HMAC of
+// both with the agent's secret, so an app can't guess (and then read or
+// poison) the cache of another one by writing its own Containerfile.
+func CacheMountID(secret []byte, appName, dir string) string {
+	h := hmac.New(sha256.New, secret)
+	fmt.Fprintf(h, "%s\x00%s", appName, dir)
+	return "tsuru-cache-" + hex.EncodeToString(h.Sum(nil))
+}
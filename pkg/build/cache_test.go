@@ -0,0 +1,128 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestDeployCacheBust(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+
+	newRequest := func(policy pb.CachePolicy, data string, envs map[string]string) *pb.BuildRequest {
+		return &pb.BuildRequest{
+			SourceImage: "tsuru/python:latest",
+			Data:        []byte(data),
+			App:         &pb.TsuruApp{Name: "my-app", EnvVars: envs},
+			CachePolicy: policy,
+		}
+	}
+
+	t.Run("always", func(t *testing.T) {
+		for _, policy := range []pb.CachePolicy{pb.CachePolicy_CACHE_POLICY_UNSPECIFIED, pb.CachePolicy_CACHE_POLICY_ALWAYS} {
+			value, ok := DeployCacheBust(newRequest(policy, "app data", nil), nil, "sha256:abc", now)
+			assert.True(t, ok)
+			assert.Equal(t, "1700000000", value)
+		}
+	})
+
+	t.Run("always w/ source date epoch", func(t *testing.T) {
+		for _, policy := range []pb.CachePolicy{pb.CachePolicy_CACHE_POLICY_UNSPECIFIED, pb.CachePolicy_CACHE_POLICY_ALWAYS} {
+			r := newRequest(policy, "app data", nil)
+			r.SourceDateEpoch = &timestamppb.Timestamp{Seconds: 1600000000}
+
+			value, ok := DeployCacheBust(r, r.App.EnvVars, "sha256:abc", now)
+			assert.True(t, ok)
+			assert.Equal(t, "1700000000", value)
+		}
+	})
+
+	t.Run("never", func(t *testing.T) {
+		value, ok := DeployCacheBust(newRequest(pb.CachePolicy_CACHE_POLICY_NEVER, "app data", nil), nil, "sha256:abc", now)
+		assert.False(t, ok)
+		assert.Empty(t, value)
+	})
+
+	t.Run("content", func(t *testing.T) {
+		envs := map[string]string{"DEBUG": "false", "PORT": "8888"}
+		bust := func(r *pb.BuildRequest, digest string) string {
+			value, ok := DeployCacheBust(r, r.App.EnvVars, digest, now)
+			assert.True(t, ok)
+			return value
+		}
+
+		base := bust(newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "app data", envs), "sha256:abc")
+		assert.Len(t, base, 64)
+
+		assert.Equal(t, base, bust(newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "app data", map[string]string{"PORT": "8888", "DEBUG": "false"}), "sha256:abc"))
+		assert.NotEqual(t, base, bust(newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "other app data", envs), "sha256:abc"))
+		assert.NotEqual(t, base, bust(newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "app data", map[string]string{"DEBUG": "true", "PORT": "8888"}), "sha256:abc"))
+		assert.NotEqual(t, base, bust(newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "app data", envs), "sha256:def"))
+		assert.NotEqual(t, base, bust(newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "app data", envs), ""))
+
+		remote := newRequest(pb.CachePolicy_CACHE_POLICY_CONTENT, "", envs)
+		remote.RemoteArchive = &pb.RemoteArchive{Url: "https://artifacts.example.com/my-app.tar.gz", Sha256: "c5b4b2d3"}
+		assert.NotEqual(t, base, bust(remote, "sha256:abc"))
+	})
+}
+
+func TestParseCacheDirs(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		value            string
+		expected         []string
+		expectedProblems []string
+	}{
+		"empty": {},
+
+		"valid dirs": {
+			value:    "/home/application/.npm, /home/application/.cache/pip/,,/home/application/.npm",
+			expected: []string{"/home/application/.cache/pip", "/home/application/.npm"},
+		},
+
+		"invalid dirs": {
+			value:    "relative/dir,/,/home/application/my cache,/root/.m2",
+			expected: []string{"/root/.m2"},
+			expectedProblems: []string{
+				`cache dir "relative/dir" must be an absolute path (other than /) with only letters, digits and . _ - / characters`,
+				`cache dir "/" must be an absolute path (other than /) with only letters, digits and . _ - / characters`,
+				`cache dir "/home/application/my cache" must be an absolute path (other than /) with only letters, digits and . _ - / characters`,
+			},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dirs, problems := ParseCacheDirs(tt.value)
+			assert.Equal(t, tt.expected, dirs)
+			assert.Equal(t, tt.expectedProblems, problems)
+		})
+	}
+}
+
+func TestCacheMountID(t *testing.T) {
+	t.Parallel()
+
+	id := CacheMountID([]byte("secret"), "my-app", "/home/application/.npm")
+	assert.Equal(t, "tsuru-cache-a960283fe142c313d33cb0b514b90efaab201a0923f8b19e39a23d4d2550ef2a", id)
+
+	assert.NotEqual(t, id, CacheMountID([]byte("other secret"), "my-app", "/home/application/.npm"))
+	assert.NotEqual(t, id, CacheMountID([]byte("secret"), "other-app", "/home/application/.npm"))
+	assert.NotEqual(t, id, CacheMountID([]byte("secret"), "my-app", "/home/application/.cache/pip"))
+	assert.NotEqual(t, id, CacheMountID([]byte("secret"), "my-app-", "home/application/.npm"))
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"text/template"
+)
+
+var platformNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ContainerfileTemplates are operator-defined templates of the Containerfile
+// generated on app source builds, which replace the built-in one. They're
+// keyed by either platform name (e.g. python) or source image pattern in
+// path.Match syntax (e.g. registry.example.com/tsuru/*:latest).
+type ContainerfileTemplates map[string]*template.Template
+
+// LoadContainerfileTemplates reads and parses the template files (indexed by
+// platform name or source image pattern). Templates are also rendered against
+// sample params, so mistakes like unknown fields show up on startup.
+func LoadContainerfileTemplates(files map[string]string) (ContainerfileTemplates, error) {
+	templates := make(ContainerfileTemplates, len(files))
+
+	for key, filename := range files {
+		if key == "" {
+			return nil, errors.New("containerfile template key cannot be empty")
+		}
+
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, fmt.Errorf("invalid source image pattern %q: %w", key, err)
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read containerfile template of %q: %w", key, err)
+		}
+
+		t, err := template.New(key).Funcs(containerfileTemplateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse containerfile template of %q: %w", key, err)
+		}
+
+		if _, err = BuildContainerfileFromTemplate(t, sampleBuildContainerfileParams); err != nil {
+			return nil, fmt.Errorf("failed to render containerfile template of %q: %w", key, err)
+		}
+
+		templates[key] = t
+	}
+
+	return templates, nil
+}
+
+// Find returns the template of the platform or source image, or nil when
+// none matches. Platform names take precedence over source image patterns,
+// and longer patterns take precedence over shorter ones.
+func (t ContainerfileTemplates) Find(platform, image string) *template.Template {
+	if tmpl, found := t[platform]; found && platform != "" {
+		return tmpl
+	}
+
+	patterns := make([]string, 0, len(t))
+	for key := range t {
+		if !platformNameRegexp.MatchString(key) {
+			patterns = append(patterns, key)
+		}
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+
+		return patterns[i] < patterns[j]
+	})
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, image); matched {
+			return t[pattern]
+		}
+	}
+
+	return nil
+}
+
+var sampleBuildContainerfileParams = BuildContainerfileParams{
+	Image:             "registry.example.com/tsuru/python:latest",
+	BuildHooks:        []string{"make build"},
+	AppName:           "my-app",
+	CacheIDSecret:     []byte("secret"),
+	PlatformName:      "python",
+	CacheDirs:         []string{"/home/application/.cache/pip"},
+	Platform:          DefaultPlatformMetadata(),
+	SourceImageLabels: map[string]string{CacheDirsLabel: "/home/application/.cache/pip"},
+}
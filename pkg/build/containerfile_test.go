@@ -0,0 +1,163 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+)
+
+func TestLoadContainerfileTemplates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	writeTemplate := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	valid := writeTemplate("valid.tmpl", `FROM {{ .Image }}
+COPY ./application.tar.gz {{ .Platform.ArchivePath }}
+RUN update-ca-certificates && {{ .Platform.DeployCommand }} archive file://{{ .Platform.ArchivePath }}
+`)
+
+	cases := map[string]struct {
+		files         map[string]string
+		expectedError string
+	}{
+		"no templates": {},
+
+		"valid templates": {
+			files: map[string]string{"python": valid, "registry.example.com/tsuru/*": valid},
+		},
+
+		"empty key": {
+			files:         map[string]string{"": valid},
+			expectedError: "containerfile template key cannot be empty",
+		},
+
+		"bad image pattern": {
+			files:         map[string]string{"registry.example.com/tsuru/[": valid},
+			expectedError: `invalid source image pattern "registry.example.com/tsuru/[": syntax error in pattern`,
+		},
+
+		"file not found": {
+			files:         map[string]string{"python": filepath.Join(dir, "not-found.tmpl")},
+			expectedError: `failed to read containerfile template of "python": open ` + filepath.Join(dir, "not-found.tmpl") + `: no such file or directory`,
+		},
+
+		"template does not parse": {
+			files:         map[string]string{"python": writeTemplate("invalid.tmpl", "FROM {{ .Image ")},
+			expectedError: `failed to parse containerfile template of "python": template: python:1: unclosed action`,
+		},
+
+		"template with unknown field": {
+			files:         map[string]string{"python": writeTemplate("unknown-field.tmpl", "FROM {{ .BaseImage }}")},
+			expectedError: `failed to render containerfile template of "python": template: python:1:8: executing "python" at <.BaseImage>: can't evaluate field BaseImage in type build.BuildContainerfileParams`,
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			templates, err := LoadContainerfileTemplates(tt.files)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, templates, len(tt.files))
+		})
+	}
+}
+
+func TestContainerfileTemplates_Find(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "Containerfile.tmpl")
+	require.NoError(t, os.WriteFile(filename, []byte("FROM {{ .Image }}\n"), 0644))
+
+	templates, err := LoadContainerfileTemplates(map[string]string{
+		"python":                            filename,
+		"registry.example.com/tsuru/*":      filename,
+		"registry.example.com/tsuru/ruby:*": filename,
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		platform string
+		image    string
+		expected string
+	}{
+		{platform: "python", image: "registry.example.com/tsuru/ruby:latest", expected: "python"},
+		{platform: "ruby", image: "registry.example.com/tsuru/ruby:latest", expected: "registry.example.com/tsuru/ruby:*"},
+		{image: "registry.example.com/tsuru/go:1.20", expected: "registry.example.com/tsuru/*"},
+		{platform: "go", image: "docker.io/tsuru/go:latest"},
+		{},
+	}
+
+	for _, tt := range cases {
+		got := templates.Find(tt.platform, tt.image)
+		if tt.expected == "" {
+			assert.Nil(t, got)
+			continue
+		}
+
+		require.NotNil(t, got)
+		assert.Equal(t, tt.expected, got.Name())
+	}
+
+	var empty ContainerfileTemplates
+	assert.Nil(t, empty.Find("python", "tsuru/python:latest"))
+}
+
+func TestBuildContainerfileFromTemplate(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), "Containerfile.tmpl")
+	require.NoError(t, os.WriteFile(filename, []byte(`FROM {{ .Image }}
+COPY ./application.tar.gz {{ .Platform.ArchivePath }}
+RUN update-ca-certificates \
+{{- range .BuildHooks }}
+    && {{ shellQuote . }} \
+{{- end }}
+    && {{ .Platform.DeployCommand }} archive file://{{ .Platform.ArchivePath }} # {{ .AppName }} ({{ .PlatformName }})
+`), 0644))
+
+	templates, err := LoadContainerfileTemplates(map[string]string{"python": filename})
+	require.NoError(t, err)
+
+	got, err := BuildContainerfileFromTemplate(templates.Find("python", ""), BuildContainerfileParams{
+		Image:        "tsuru/python:latest",
+		BuildHooks:   []string{"make assets"},
+		AppName:      "my-app",
+		PlatformName: "python",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `FROM tsuru/python:latest
+COPY ./application.tar.gz /home/application/archive.tar.gz
+RUN update-ca-certificates \
+    && 'make assets' \
+    && /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz # my-app (python)
+`, got)
+
+	builtIn, err := BuildContainerfile(BuildContainerfileParams{Image: "tsuru/python:latest"})
+	require.NoError(t, err)
+
+	got, err = BuildContainerfileFromTemplate(nil, BuildContainerfileParams{Image: "tsuru/python:latest"})
+	require.NoError(t, err)
+	assert.Equal(t, builtIn, got)
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+var envVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateBuildEnvDenyPatterns checks the patterns are valid path.Match
+// patterns, e.g. *_PASSWORD.
+func ValidateBuildEnvDenyPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return fmt.Errorf("build env deny pattern %q is malformed", p)
+		}
+	}
+
+	return nil
+}
+
+func validateBuildEnvVars(app *pb.TsuruApp) error {
+	names := append([]string{}, app.GetBuildEnvVarNames()...)
+	names = append(names, sortedKeys(app.GetBuildEnvVars())...)
+
+	for _, name := range names {
+		if !envVarNameRegexp.MatchString(name) {
+			return fmt.Errorf("build env var name %q must have only letters, digits and _ characters, not starting with a digit", name)
+		}
+	}
+
+	return nil
+}
+
+// BuildEnvVars returns the env vars of app visible at build time, along with
+// the (sorted) names left out by the deny patterns.
+//
+// When app has neither build env vars nor allowlisted names, every env var is
+// visible, otherwise only the allowlisted ones are. The deny patterns apply
+// on the env vars not explicitly allowed by app, i.e. neither allowlisted nor
+// build env vars.
+func BuildEnvVars(app *pb.TsuruApp, denyPatterns []string) (map[string]string, []string) {
+	if app == nil {
+		return nil, nil
+	}
+
+	allowed := make(map[string]bool)
+	for _, name := range app.BuildEnvVarNames {
+		allowed[name] = true
+	}
+
+	restricted := len(app.BuildEnvVarNames) > 0 || len(app.BuildEnvVars) > 0
+
+	envs := make(map[string]string)
+	var denied []string
+
+	for k, v := range app.EnvVars {
+		if allowed[k] {
+			envs[k] = v
+			continue
+		}
+
+		if restricted { // runtime only
+			continue
+		}
+
+		if matchesAny(k, denyPatterns) {
+			denied = append(denied, k)
+			continue
+		}
+
+		envs[k] = v
+	}
+
+	for k, v := range app.BuildEnvVars {
+		envs[k] = v
+	}
+
+	sort.Strings(denied)
+	return envs, denied
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if matched, _ := path.Match(p, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestBuildEnvVars(t *testing.T) {
+	t.Parallel()
+
+	envs := map[string]string{
+		"DATABASE_PASSWORD": "s3cr3t",
+		"DATABASE_HOST":     "db.example.com",
+		"NPM_TOKEN":         "npm_abc",
+		"PORT":              "8888",
+	}
+
+	cases := map[string]struct {
+		app            *pb.TsuruApp
+		denyPatterns   []string
+		expected       map[string]string
+		expectedDenied []string
+	}{
+		"nil app": {},
+
+		"every env var w/o deny patterns": {
+			app:      &pb.TsuruApp{EnvVars: envs},
+			expected: envs,
+		},
+
+		"deny patterns": {
+			app:            &pb.TsuruApp{EnvVars: envs},
+			denyPatterns:   []string{"*_PASSWORD", "*_TOKEN"},
+			expected:       map[string]string{"DATABASE_HOST": "db.example.com", "PORT": "8888"},
+			expectedDenied: []string{"DATABASE_PASSWORD", "NPM_TOKEN"},
+		},
+
+		"allowlisted names bypass deny patterns": {
+			app:          &pb.TsuruApp{EnvVars: envs, BuildEnvVarNames: []string{"NPM_TOKEN", "MISSING"}},
+			denyPatterns: []string{"*_PASSWORD", "*_TOKEN"},
+			expected:     map[string]string{"NPM_TOKEN": "npm_abc"},
+		},
+
+		"build env vars only": {
+			app:          &pb.TsuruApp{EnvVars: envs, BuildEnvVars: map[string]string{"NODE_ENV": "production", "PORT": "3000"}},
+			denyPatterns: []string{"*_PASSWORD"},
+			expected:     map[string]string{"NODE_ENV": "production", "PORT": "3000"},
+		},
+
+		"build env vars take precedence over allowlisted ones": {
+			app: &pb.TsuruApp{
+				EnvVars:          envs,
+				BuildEnvVarNames: []string{"DATABASE_HOST", "PORT"},
+				BuildEnvVars:     map[string]string{"DATABASE_HOST": "localhost"},
+			},
+			expected: map[string]string{"DATABASE_HOST": "localhost", "PORT": "8888"},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, denied := BuildEnvVars(tt.app, tt.denyPatterns)
+			if tt.expected == nil {
+				assert.Empty(t, got)
+			} else {
+				assert.Equal(t, tt.expected, got)
+			}
+			assert.Equal(t, tt.expectedDenied, denied)
+		})
+	}
+}
+
+func TestValidateBuildEnvDenyPatterns(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateBuildEnvDenyPatterns(nil))
+	assert.NoError(t, ValidateBuildEnvDenyPatterns([]string{"*_PASSWORD", "AWS_SECRET_*", "SECRET_KEY"}))
+	assert.EqualError(t, ValidateBuildEnvDenyPatterns([]string{"*_PASSWORD", "[*_TOKEN"}), `build env deny pattern "[*_TOKEN" is malformed`)
+	assert.EqualError(t, ValidateBuildEnvDenyPatterns([]string{""}), `build env deny pattern "" is malformed`)
+}
@@ -16,7 +16,8 @@ import (
 var _ build.Builder = (*FakeBuilder)(nil)
 
 type FakeBuilder struct {
-	OnBuild func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error)
+	OnBuild   func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error)
+	OnPreview func(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error)
 }
 
 func (b *FakeBuilder) Build(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error) {
@@ -26,3 +27,11 @@ func (b *FakeBuilder) Build(ctx context.Context, r *pb.BuildRequest, w io.Writer
 
 	return b.OnBuild(ctx, r, w)
 }
+
+func (b *FakeBuilder) Preview(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error) {
+	if b.OnPreview == nil {
+		return nil, errors.New("fake: method not implemented")
+	}
+
+	return b.OnPreview(ctx, r)
+}
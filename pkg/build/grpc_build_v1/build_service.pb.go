@@ -13,6 +13,8 @@ package grpc_build_v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -24,6 +26,161 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ProgressMode int32
+
+const (
+	ProgressMode_PROGRESS_MODE_UNSPECIFIED ProgressMode = 0
+	ProgressMode_PROGRESS_MODE_PLAIN       ProgressMode = 1 // every step with its logs, as in docker build --progress plain
+	ProgressMode_PROGRESS_MODE_TTY         ProgressMode = 2 // condensed, one line per finished step and logs of failed steps only
+	// BuildKit's solve status as JSON lines, as in buildctl build --progress rawjson.
+	// Other lines of the output are sent as {"Message": "..."} JSON lines.
+	ProgressMode_PROGRESS_MODE_RAWJSON ProgressMode = 3
+)
+
+// Enum value maps for ProgressMode.
+var (
+	ProgressMode_name = map[int32]string{
+		0: "PROGRESS_MODE_UNSPECIFIED",
+		1: "PROGRESS_MODE_PLAIN",
+		2: "PROGRESS_MODE_TTY",
+		3: "PROGRESS_MODE_RAWJSON",
+	}
+	ProgressMode_value = map[string]int32{
+		"PROGRESS_MODE_UNSPECIFIED": 0,
+		"PROGRESS_MODE_PLAIN":       1,
+		"PROGRESS_MODE_TTY":         2,
+		"PROGRESS_MODE_RAWJSON":     3,
+	}
+)
+
+func (x ProgressMode) Enum() *ProgressMode {
+	p := new(ProgressMode)
+	*p = x
+	return p
+}
+
+func (x ProgressMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProgressMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[0].Descriptor()
+}
+
+func (ProgressMode) Type() protoreflect.EnumType {
+	return &file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[0]
+}
+
+func (x ProgressMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProgressMode.Descriptor instead.
+func (ProgressMode) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{0}
+}
+
+type ProgressTimestamp int32
+
+const (
+	ProgressTimestamp_PROGRESS_TIMESTAMP_NONE       ProgressTimestamp = 0
+	ProgressTimestamp_PROGRESS_TIMESTAMP_ELAPSED    ProgressTimestamp = 1 // time since the build started, e.g. [  12.345s]
+	ProgressTimestamp_PROGRESS_TIMESTAMP_WALL_CLOCK ProgressTimestamp = 2 // UTC time in RFC 3339 format, e.g. 2023-06-01T12:00:00.000Z
+)
+
+// Enum value maps for ProgressTimestamp.
+var (
+	ProgressTimestamp_name = map[int32]string{
+		0: "PROGRESS_TIMESTAMP_NONE",
+		1: "PROGRESS_TIMESTAMP_ELAPSED",
+		2: "PROGRESS_TIMESTAMP_WALL_CLOCK",
+	}
+	ProgressTimestamp_value = map[string]int32{
+		"PROGRESS_TIMESTAMP_NONE":       0,
+		"PROGRESS_TIMESTAMP_ELAPSED":    1,
+		"PROGRESS_TIMESTAMP_WALL_CLOCK": 2,
+	}
+)
+
+func (x ProgressTimestamp) Enum() *ProgressTimestamp {
+	p := new(ProgressTimestamp)
+	*p = x
+	return p
+}
+
+func (x ProgressTimestamp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProgressTimestamp) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[1].Descriptor()
+}
+
+func (ProgressTimestamp) Type() protoreflect.EnumType {
+	return &file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[1]
+}
+
+func (x ProgressTimestamp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProgressTimestamp.Descriptor instead.
+func (ProgressTimestamp) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{1}
+}
+
+type CachePolicy int32
+
+const (
+	CachePolicy_CACHE_POLICY_UNSPECIFIED CachePolicy = 0
+	CachePolicy_CACHE_POLICY_ALWAYS      CachePolicy = 1 // deploy's script always runs, i.e. the cache is busted on every build
+	CachePolicy_CACHE_POLICY_CONTENT     CachePolicy = 2 // deploy's script runs only when app source data, env vars or source image change
+	CachePolicy_CACHE_POLICY_NEVER       CachePolicy = 3 // deploy's script cache is never busted
+)
+
+// Enum value maps for CachePolicy.
+var (
+	CachePolicy_name = map[int32]string{
+		0: "CACHE_POLICY_UNSPECIFIED",
+		1: "CACHE_POLICY_ALWAYS",
+		2: "CACHE_POLICY_CONTENT",
+		3: "CACHE_POLICY_NEVER",
+	}
+	CachePolicy_value = map[string]int32{
+		"CACHE_POLICY_UNSPECIFIED": 0,
+		"CACHE_POLICY_ALWAYS":      1,
+		"CACHE_POLICY_CONTENT":     2,
+		"CACHE_POLICY_NEVER":       3,
+	}
+)
+
+func (x CachePolicy) Enum() *CachePolicy {
+	p := new(CachePolicy)
+	*p = x
+	return p
+}
+
+func (x CachePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CachePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[2].Descriptor()
+}
+
+func (CachePolicy) Type() protoreflect.EnumType {
+	return &file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[2]
+}
+
+func (x CachePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CachePolicy.Descriptor instead.
+func (CachePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{2}
+}
+
 type BuildKind int32
 
 const (
@@ -75,11 +232,11 @@ func (x BuildKind) String() string {
 }
 
 func (BuildKind) Descriptor() protoreflect.EnumDescriptor {
-	return file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[0].Descriptor()
+	return file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[3].Descriptor()
 }
 
 func (BuildKind) Type() protoreflect.EnumType {
-	return &file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[0]
+	return &file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[3]
 }
 
 func (x BuildKind) Number() protoreflect.EnumNumber {
@@ -88,7 +245,59 @@ func (x BuildKind) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use BuildKind.Descriptor instead.
 func (BuildKind) EnumDescriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{0}
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{3}
+}
+
+type ProvenanceMode int32
+
+const (
+	ProvenanceMode_PROVENANCE_MODE_UNSPECIFIED ProvenanceMode = 0 // uses the mode from deploy-agent's config
+	ProvenanceMode_PROVENANCE_MODE_DISABLED    ProvenanceMode = 1 // same as unspecified, i.e. it cannot disable the mode from deploy-agent's config
+	ProvenanceMode_PROVENANCE_MODE_MIN         ProvenanceMode = 2
+	ProvenanceMode_PROVENANCE_MODE_MAX         ProvenanceMode = 3
+)
+
+// Enum value maps for ProvenanceMode.
+var (
+	ProvenanceMode_name = map[int32]string{
+		0: "PROVENANCE_MODE_UNSPECIFIED",
+		1: "PROVENANCE_MODE_DISABLED",
+		2: "PROVENANCE_MODE_MIN",
+		3: "PROVENANCE_MODE_MAX",
+	}
+	ProvenanceMode_value = map[string]int32{
+		"PROVENANCE_MODE_UNSPECIFIED": 0,
+		"PROVENANCE_MODE_DISABLED":    1,
+		"PROVENANCE_MODE_MIN":         2,
+		"PROVENANCE_MODE_MAX":         3,
+	}
+)
+
+func (x ProvenanceMode) Enum() *ProvenanceMode {
+	p := new(ProvenanceMode)
+	*p = x
+	return p
+}
+
+func (x ProvenanceMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProvenanceMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[4].Descriptor()
+}
+
+func (ProvenanceMode) Type() protoreflect.EnumType {
+	return &file_pkg_build_grpc_build_v1_build_service_proto_enumTypes[4]
+}
+
+func (x ProvenanceMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProvenanceMode.Descriptor instead.
+func (ProvenanceMode) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{4}
 }
 
 type BuildRequest struct {
@@ -117,6 +326,7 @@ type BuildRequest struct {
 	// DestinationImages are the tags of the container image after build.
 	DestinationImages []string `protobuf:"bytes,5,rep,name=destination_images,json=destinationImages,proto3" json:"destination_images,omitempty"`
 	// Data is the app's source data (or container context).
+	// It must be an archive in either tar, tar.gz, tar.zst or zip format.
 	// Cannot exceed 2^32 of size.
 	//
 	// See more: https://developers.google.com/protocol-buffers/docs/proto3#scalar
@@ -125,6 +335,35 @@ type BuildRequest struct {
 	Containerfile string `protobuf:"bytes,7,opt,name=Containerfile,proto3" json:"Containerfile,omitempty"`
 	// PushOptions contains the options push the generated images.
 	PushOptions *PushOptions `protobuf:"bytes,10,opt,name=push_options,json=pushOptions,proto3" json:"push_options,omitempty"`
+	// RemoteArchive is the app's source data stored in a remote location.
+	// It's an alternative to the Data field, so they're mutually exclusive.
+	//
+	// NOTE: only used when build kind is BUILD_KIND_APP_BUILD_WITH_SOURCE_UPLOAD.
+	RemoteArchive *RemoteArchive `protobuf:"bytes,11,opt,name=remote_archive,json=remoteArchive,proto3" json:"remote_archive,omitempty"`
+	// Attestations contains the options to generate attestations (e.g. SBOM) of the container image.
+	// They're enabled in addition to the ones enabled in deploy-agent's config.
+	Attestations *AttestationOptions `protobuf:"bytes,12,opt,name=attestations,proto3" json:"attestations,omitempty"`
+	// TsuruConfigSearch contains additional locations of Procfile and TsuruYAML.
+	// They take precedence over the ones set in deploy-agent's config.
+	TsuruConfigSearch *TsuruConfigSearch `protobuf:"bytes,13,opt,name=tsuru_config_search,json=tsuruConfigSearch,proto3" json:"tsuru_config_search,omitempty"`
+	// SourceDateEpoch makes the build reproducible, i.e. identical inputs give identical
+	// image digests. It's passed to BuildKit as SOURCE_DATE_EPOCH build arg, and it's the
+	// mtime of every file in the application.tar.gz generated from app source data.
+	//
+	// See more: https://reproducible-builds.org/docs/source-date-epoch/
+	SourceDateEpoch *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=source_date_epoch,json=sourceDateEpoch,proto3" json:"source_date_epoch,omitempty"`
+	// CachePolicy defines when the layer cache of deploy's script step (app source builds) is reused.
+	// When unspecified, it's CACHE_POLICY_ALWAYS, even if SourceDateEpoch is set. Reproducible builds
+	// should opt into CACHE_POLICY_CONTENT (or CACHE_POLICY_NEVER) explicitly.
+	CachePolicy CachePolicy `protobuf:"varint,15,opt,name=cache_policy,json=cachePolicy,proto3,enum=grpc_build_v1.CachePolicy" json:"cache_policy,omitempty"`
+	// BuildHooks contains the options of how tsuru.yaml's build hooks run (app source builds).
+	BuildHooks *BuildHookOptions `protobuf:"bytes,16,opt,name=build_hooks,json=buildHooks,proto3" json:"build_hooks,omitempty"`
+	// ProgressMode is how BuildKit's progress is printed in the build output.
+	// When unspecified, it's PROGRESS_MODE_PLAIN.
+	ProgressMode ProgressMode `protobuf:"varint,17,opt,name=progress_mode,json=progressMode,proto3,enum=grpc_build_v1.ProgressMode" json:"progress_mode,omitempty"`
+	// ProgressTimestamp prefixes every line of the build output with a timestamp, if set.
+	// Not supported with PROGRESS_MODE_RAWJSON, whose output is already timestamped.
+	ProgressTimestamp ProgressTimestamp `protobuf:"varint,18,opt,name=progress_timestamp,json=progressTimestamp,proto3,enum=grpc_build_v1.ProgressTimestamp" json:"progress_timestamp,omitempty"`
 }
 
 func (x *BuildRequest) Reset() {
@@ -215,6 +454,122 @@ func (x *BuildRequest) GetPushOptions() *PushOptions {
 	return nil
 }
 
+func (x *BuildRequest) GetRemoteArchive() *RemoteArchive {
+	if x != nil {
+		return x.RemoteArchive
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetAttestations() *AttestationOptions {
+	if x != nil {
+		return x.Attestations
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetTsuruConfigSearch() *TsuruConfigSearch {
+	if x != nil {
+		return x.TsuruConfigSearch
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetSourceDateEpoch() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SourceDateEpoch
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetCachePolicy() CachePolicy {
+	if x != nil {
+		return x.CachePolicy
+	}
+	return CachePolicy_CACHE_POLICY_UNSPECIFIED
+}
+
+func (x *BuildRequest) GetBuildHooks() *BuildHookOptions {
+	if x != nil {
+		return x.BuildHooks
+	}
+	return nil
+}
+
+func (x *BuildRequest) GetProgressMode() ProgressMode {
+	if x != nil {
+		return x.ProgressMode
+	}
+	return ProgressMode_PROGRESS_MODE_UNSPECIFIED
+}
+
+func (x *BuildRequest) GetProgressTimestamp() ProgressTimestamp {
+	if x != nil {
+		return x.ProgressTimestamp
+	}
+	return ProgressTimestamp_PROGRESS_TIMESTAMP_NONE
+}
+
+type BuildHookOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// SeparateSteps runs each build hook as its own RUN step after the deploy's script step, so
+	// every hook has its own output and layer cache. Otherwise, hooks are chained into the deploy's
+	// script step.
+	SeparateSteps bool `protobuf:"varint,1,opt,name=separate_steps,json=separateSteps,proto3" json:"separate_steps,omitempty"`
+	// Timeout is the max duration of each build hook, if any. It requires separate steps and the
+	// timeout command (either coreutils or busybox) in the platform's container image.
+	Timeout *durationpb.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+}
+
+func (x *BuildHookOptions) Reset() {
+	*x = BuildHookOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuildHookOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildHookOptions) ProtoMessage() {}
+
+func (x *BuildHookOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildHookOptions.ProtoReflect.Descriptor instead.
+func (*BuildHookOptions) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BuildHookOptions) GetSeparateSteps() bool {
+	if x != nil {
+		return x.SeparateSteps
+	}
+	return false
+}
+
+func (x *BuildHookOptions) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
 type BuildResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -230,7 +585,7 @@ type BuildResponse struct {
 func (x *BuildResponse) Reset() {
 	*x = BuildResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[1]
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -243,7 +598,7 @@ func (x *BuildResponse) String() string {
 func (*BuildResponse) ProtoMessage() {}
 
 func (x *BuildResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[1]
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -256,7 +611,7 @@ func (x *BuildResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildResponse.ProtoReflect.Descriptor instead.
 func (*BuildResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{1}
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{2}
 }
 
 func (m *BuildResponse) GetData() isBuildResponse_Data {
@@ -298,34 +653,43 @@ func (*BuildResponse_Output) isBuildResponse_Data() {}
 
 func (*BuildResponse_TsuruConfig) isBuildResponse_Data() {}
 
-type TsuruApp struct {
+type BuildPreview struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Name is the Tsuru app name.
-	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	// EnvVars are the enviroment variables set on app.
-	EnvVars map[string]string `protobuf:"bytes,3,rep,name=env_vars,json=envVars,proto3" json:"env_vars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-}
-
-func (x *TsuruApp) Reset() {
-	*x = TsuruApp{}
+	// Containerfile is the Containerfile (Dockerfile) which would be built.
+	Containerfile string `protobuf:"bytes,1,opt,name=containerfile,proto3" json:"containerfile,omitempty"`
+	// TsuruConfig holds the Tsuru config files (tsuru.yaml and Procfile) found
+	// in the app source data, if any.
+	TsuruConfig *TsuruConfig `protobuf:"bytes,2,opt,name=tsuru_config,json=tsuruConfig,proto3" json:"tsuru_config,omitempty"`
+	// BuildHooks are the build hooks of tsuru.yaml, in the same order.
+	BuildHooks []string `protobuf:"bytes,3,rep,name=build_hooks,json=buildHooks,proto3" json:"build_hooks,omitempty"`
+	// ContextFiles are the paths (sorted) of files sent to the build, after the
+	// ignore file exclusions: app source data files (.tsuruignore) or container
+	// file context files (.dockerignore).
+	ContextFiles []string `protobuf:"bytes,4,rep,name=context_files,json=contextFiles,proto3" json:"context_files,omitempty"`
+	// FrontendAttrs are the attributes passed to the Dockerfile frontend.
+	FrontendAttrs map[string]string `protobuf:"bytes,5,rep,name=frontend_attrs,json=frontendAttrs,proto3" json:"frontend_attrs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *BuildPreview) Reset() {
+	*x = BuildPreview{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[2]
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TsuruApp) String() string {
+func (x *BuildPreview) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TsuruApp) ProtoMessage() {}
+func (*BuildPreview) ProtoMessage() {}
 
-func (x *TsuruApp) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[2]
+func (x *BuildPreview) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -336,51 +700,85 @@ func (x *TsuruApp) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TsuruApp.ProtoReflect.Descriptor instead.
-func (*TsuruApp) Descriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use BuildPreview.ProtoReflect.Descriptor instead.
+func (*BuildPreview) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *TsuruApp) GetName() string {
+func (x *BuildPreview) GetContainerfile() string {
 	if x != nil {
-		return x.Name
+		return x.Containerfile
 	}
 	return ""
 }
 
-func (x *TsuruApp) GetEnvVars() map[string]string {
+func (x *BuildPreview) GetTsuruConfig() *TsuruConfig {
 	if x != nil {
-		return x.EnvVars
+		return x.TsuruConfig
 	}
 	return nil
 }
 
-type TsuruPlatform struct {
+func (x *BuildPreview) GetBuildHooks() []string {
+	if x != nil {
+		return x.BuildHooks
+	}
+	return nil
+}
+
+func (x *BuildPreview) GetContextFiles() []string {
+	if x != nil {
+		return x.ContextFiles
+	}
+	return nil
+}
+
+func (x *BuildPreview) GetFrontendAttrs() map[string]string {
+	if x != nil {
+		return x.FrontendAttrs
+	}
+	return nil
+}
+
+type TsuruApp struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Name is the Tsuru platform name.
+	// Name is the Tsuru app name.
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// EnvVars are the enviroment variables set on app. Unless restricted by BuildEnvVars or
+	// BuildEnvVarNames, they're also set at build time (except the ones matching the deny
+	// patterns of deploy-agent's config).
+	EnvVars map[string]string `protobuf:"bytes,3,rep,name=env_vars,json=envVars,proto3" json:"env_vars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Platform is the name of app's platform (e.g. python), if any.
+	Platform string `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
+	// BuildEnvVars are the environment variables set only at build time (e.g. the token of a
+	// private package registry). They take precedence over the ones in EnvVars.
+	BuildEnvVars map[string]string `protobuf:"bytes,5,rep,name=build_env_vars,json=buildEnvVars,proto3" json:"build_env_vars,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// BuildEnvVarNames are the names of EnvVars also set at build time. When either it or
+	// BuildEnvVars is set, the other EnvVars are set only at runtime. Names listed here (and
+	// BuildEnvVars) bypass the deny patterns of deploy-agent's config.
+	BuildEnvVarNames []string `protobuf:"bytes,6,rep,name=build_env_var_names,json=buildEnvVarNames,proto3" json:"build_env_var_names,omitempty"`
 }
 
-func (x *TsuruPlatform) Reset() {
-	*x = TsuruPlatform{}
+func (x *TsuruApp) Reset() {
+	*x = TsuruApp{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[3]
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TsuruPlatform) String() string {
+func (x *TsuruApp) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TsuruPlatform) ProtoMessage() {}
+func (*TsuruApp) ProtoMessage() {}
 
-func (x *TsuruPlatform) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[3]
+func (x *TsuruApp) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -391,9 +789,85 @@ func (x *TsuruPlatform) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TsuruPlatform.ProtoReflect.Descriptor instead.
+// Deprecated: Use TsuruApp.ProtoReflect.Descriptor instead.
+func (*TsuruApp) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TsuruApp) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TsuruApp) GetEnvVars() map[string]string {
+	if x != nil {
+		return x.EnvVars
+	}
+	return nil
+}
+
+func (x *TsuruApp) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *TsuruApp) GetBuildEnvVars() map[string]string {
+	if x != nil {
+		return x.BuildEnvVars
+	}
+	return nil
+}
+
+func (x *TsuruApp) GetBuildEnvVarNames() []string {
+	if x != nil {
+		return x.BuildEnvVarNames
+	}
+	return nil
+}
+
+type TsuruPlatform struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name is the Tsuru platform name.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *TsuruPlatform) Reset() {
+	*x = TsuruPlatform{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruPlatform) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruPlatform) ProtoMessage() {}
+
+func (x *TsuruPlatform) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruPlatform.ProtoReflect.Descriptor instead.
 func (*TsuruPlatform) Descriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{3}
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *TsuruPlatform) GetName() string {
@@ -417,7 +891,7 @@ type PushOptions struct {
 func (x *PushOptions) Reset() {
 	*x = PushOptions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[4]
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -430,7 +904,7 @@ func (x *PushOptions) String() string {
 func (*PushOptions) ProtoMessage() {}
 
 func (x *PushOptions) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[4]
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -443,51 +917,1183 @@ func (x *PushOptions) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PushOptions.ProtoReflect.Descriptor instead.
 func (*PushOptions) Descriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{4}
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PushOptions) GetDisable() bool {
 	if x != nil {
 		return x.Disable
 	}
-	return false
+	return false
+}
+
+func (x *PushOptions) GetInsecureRegistry() bool {
+	if x != nil {
+		return x.InsecureRegistry
+	}
+	return false
+}
+
+type RemoteArchive struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// URL is the HTTP(S) address of the archive (e.g. https://artifacts.example.com/my-app/v1.tar.gz).
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// SHA256 is the hex-encoded SHA-256 digest of the archive content.
+	Sha256 string `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+}
+
+func (x *RemoteArchive) Reset() {
+	*x = RemoteArchive{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoteArchive) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteArchive) ProtoMessage() {}
+
+func (x *RemoteArchive) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteArchive.ProtoReflect.Descriptor instead.
+func (*RemoteArchive) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RemoteArchive) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *RemoteArchive) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+type TsuruConfigSearch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Dirs are absolute paths searched after the container image's working dir
+	// and before the default dirs (e.g. /home/application/current).
+	Dirs []string `protobuf:"bytes,1,rep,name=dirs,proto3" json:"dirs,omitempty"`
+	// TsuruYamlNames are file names tried after the default ones (e.g. tsuru.yaml).
+	TsuruYamlNames []string `protobuf:"bytes,2,rep,name=tsuru_yaml_names,json=tsuruYamlNames,proto3" json:"tsuru_yaml_names,omitempty"`
+	// ProcfileNames are file names tried after Procfile.
+	ProcfileNames []string `protobuf:"bytes,3,rep,name=procfile_names,json=procfileNames,proto3" json:"procfile_names,omitempty"`
+}
+
+func (x *TsuruConfigSearch) Reset() {
+	*x = TsuruConfigSearch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruConfigSearch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruConfigSearch) ProtoMessage() {}
+
+func (x *TsuruConfigSearch) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruConfigSearch.ProtoReflect.Descriptor instead.
+func (*TsuruConfigSearch) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TsuruConfigSearch) GetDirs() []string {
+	if x != nil {
+		return x.Dirs
+	}
+	return nil
+}
+
+func (x *TsuruConfigSearch) GetTsuruYamlNames() []string {
+	if x != nil {
+		return x.TsuruYamlNames
+	}
+	return nil
+}
+
+func (x *TsuruConfigSearch) GetProcfileNames() []string {
+	if x != nil {
+		return x.ProcfileNames
+	}
+	return nil
+}
+
+type AttestationOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// SBOM enables the Software Bill of Materials attestation. When false, it's
+	// still generated if enabled in deploy-agent's config.
+	Sbom bool `protobuf:"varint,1,opt,name=sbom,proto3" json:"sbom,omitempty"`
+	// Provenance enables the SLSA provenance attestation. The most detailed mode
+	// between this and the one in deploy-agent's config is used.
+	Provenance ProvenanceMode `protobuf:"varint,2,opt,name=provenance,proto3,enum=grpc_build_v1.ProvenanceMode" json:"provenance,omitempty"`
+}
+
+func (x *AttestationOptions) Reset() {
+	*x = AttestationOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AttestationOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttestationOptions) ProtoMessage() {}
+
+func (x *AttestationOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttestationOptions.ProtoReflect.Descriptor instead.
+func (*AttestationOptions) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AttestationOptions) GetSbom() bool {
+	if x != nil {
+		return x.Sbom
+	}
+	return false
+}
+
+func (x *AttestationOptions) GetProvenance() ProvenanceMode {
+	if x != nil {
+		return x.Provenance
+	}
+	return ProvenanceMode_PROVENANCE_MODE_UNSPECIFIED
+}
+
+type Attestation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Digest is the digest of the attestation manifest.
+	Digest string `protobuf:"bytes,1,opt,name=digest,proto3" json:"digest,omitempty"`
+	// SubjectDigest is the digest of the container image manifest which the attestation refers to.
+	SubjectDigest string `protobuf:"bytes,2,opt,name=subject_digest,json=subjectDigest,proto3" json:"subject_digest,omitempty"`
+	// PredicateTypes are the in-toto predicate types within the attestation (e.g. https://spdx.dev/Document).
+	PredicateTypes []string `protobuf:"bytes,3,rep,name=predicate_types,json=predicateTypes,proto3" json:"predicate_types,omitempty"`
+}
+
+func (x *Attestation) Reset() {
+	*x = Attestation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Attestation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attestation) ProtoMessage() {}
+
+func (x *Attestation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attestation.ProtoReflect.Descriptor instead.
+func (*Attestation) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Attestation) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *Attestation) GetSubjectDigest() string {
+	if x != nil {
+		return x.SubjectDigest
+	}
+	return ""
+}
+
+func (x *Attestation) GetPredicateTypes() []string {
+	if x != nil {
+		return x.PredicateTypes
+	}
+	return nil
+}
+
+type ContainerImageConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Entrypoint []string `protobuf:"bytes,1,rep,name=entrypoint,proto3" json:"entrypoint,omitempty"`
+	Cmd        []string `protobuf:"bytes,2,rep,name=cmd,proto3" json:"cmd,omitempty"`
+	// ExposedPorts are the ports in the container image format (e.g. 8080/tcp), sorted.
+	ExposedPorts []string `protobuf:"bytes,3,rep,name=exposed_ports,json=exposedPorts,proto3" json:"exposed_ports,omitempty"`
+	WorkingDir   string   `protobuf:"bytes,4,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	// Env are the default env vars (in KEY=value format).
+	Env    []string          `protobuf:"bytes,5,rep,name=env,proto3" json:"env,omitempty"`
+	User   string            `protobuf:"bytes,6,opt,name=user,proto3" json:"user,omitempty"`
+	Labels map[string]string `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Volumes are the mount points, sorted.
+	Volumes     []string                   `protobuf:"bytes,8,rep,name=volumes,proto3" json:"volumes,omitempty"`
+	StopSignal  string                     `protobuf:"bytes,9,opt,name=stop_signal,json=stopSignal,proto3" json:"stop_signal,omitempty"`
+	Healthcheck *ContainerImageHealthcheck `protobuf:"bytes,10,opt,name=healthcheck,proto3" json:"healthcheck,omitempty"`
+	// Digest is the digest of the exported container image, i.e. the image manifest
+	// (or the image index when attestations are enabled).
+	Digest string `protobuf:"bytes,11,opt,name=digest,proto3" json:"digest,omitempty"`
+	// Size is the sum of the config and (compressed) layer sizes in bytes.
+	// It's zero when push is disabled.
+	Size int64 `protobuf:"varint,12,opt,name=size,proto3" json:"size,omitempty"`
+	// Ports are the exposed ports parsed, sorted by port number and protocol.
+	Ports []*ContainerImagePort `protobuf:"bytes,13,rep,name=ports,proto3" json:"ports,omitempty"`
+}
+
+func (x *ContainerImageConfig) Reset() {
+	*x = ContainerImageConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContainerImageConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerImageConfig) ProtoMessage() {}
+
+func (x *ContainerImageConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerImageConfig.ProtoReflect.Descriptor instead.
+func (*ContainerImageConfig) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ContainerImageConfig) GetEntrypoint() []string {
+	if x != nil {
+		return x.Entrypoint
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetCmd() []string {
+	if x != nil {
+		return x.Cmd
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetExposedPorts() []string {
+	if x != nil {
+		return x.ExposedPorts
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *ContainerImageConfig) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *ContainerImageConfig) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetVolumes() []string {
+	if x != nil {
+		return x.Volumes
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetStopSignal() string {
+	if x != nil {
+		return x.StopSignal
+	}
+	return ""
+}
+
+func (x *ContainerImageConfig) GetHealthcheck() *ContainerImageHealthcheck {
+	if x != nil {
+		return x.Healthcheck
+	}
+	return nil
+}
+
+func (x *ContainerImageConfig) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *ContainerImageConfig) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ContainerImageConfig) GetPorts() []*ContainerImagePort {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+type ContainerImageHealthcheck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Test is the healthcheck command, e.g. ["CMD-SHELL", "curl -f http://localhost/"].
+	Test        []string             `protobuf:"bytes,1,rep,name=test,proto3" json:"test,omitempty"`
+	Interval    *durationpb.Duration `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`
+	Timeout     *durationpb.Duration `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	StartPeriod *durationpb.Duration `protobuf:"bytes,4,opt,name=start_period,json=startPeriod,proto3" json:"start_period,omitempty"`
+	Retries     int32                `protobuf:"varint,5,opt,name=retries,proto3" json:"retries,omitempty"`
+}
+
+func (x *ContainerImageHealthcheck) Reset() {
+	*x = ContainerImageHealthcheck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContainerImageHealthcheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerImageHealthcheck) ProtoMessage() {}
+
+func (x *ContainerImageHealthcheck) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerImageHealthcheck.ProtoReflect.Descriptor instead.
+func (*ContainerImageHealthcheck) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ContainerImageHealthcheck) GetTest() []string {
+	if x != nil {
+		return x.Test
+	}
+	return nil
+}
+
+func (x *ContainerImageHealthcheck) GetInterval() *durationpb.Duration {
+	if x != nil {
+		return x.Interval
+	}
+	return nil
+}
+
+func (x *ContainerImageHealthcheck) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+func (x *ContainerImageHealthcheck) GetStartPeriod() *durationpb.Duration {
+	if x != nil {
+		return x.StartPeriod
+	}
+	return nil
+}
+
+func (x *ContainerImageHealthcheck) GetRetries() int32 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+type ContainerImagePort struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Port int32 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	// Protocol is either tcp, udp or sctp.
+	Protocol string `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+}
+
+func (x *ContainerImagePort) Reset() {
+	*x = ContainerImagePort{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ContainerImagePort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerImagePort) ProtoMessage() {}
+
+func (x *ContainerImagePort) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerImagePort.ProtoReflect.Descriptor instead.
+func (*ContainerImagePort) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ContainerImagePort) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *ContainerImagePort) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+type TsuruConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Procfile definition found during the build.
+	Procfile string `protobuf:"bytes,1,opt,name=procfile,proto3" json:"procfile,omitempty"`
+	// TsuruYAML definition found during the build.
+	TsuruYaml string `protobuf:"bytes,2,opt,name=tsuru_yaml,json=tsuruYaml,proto3" json:"tsuru_yaml,omitempty"`
+	// ContainerImageConfig found in the container image registry.
+	ImageConfig *ContainerImageConfig `protobuf:"bytes,3,opt,name=image_config,json=imageConfig,proto3" json:"image_config,omitempty"`
+	// Attestations pushed along with the container image, if any.
+	Attestations []*Attestation `protobuf:"bytes,4,rep,name=attestations,proto3" json:"attestations,omitempty"`
+	// Processes are the valid process definitions of Procfile, in the same order.
+	Processes []*TsuruProcess `protobuf:"bytes,5,rep,name=processes,proto3" json:"processes,omitempty"`
+	// TsuruYamlData is the TsuruYAML definition parsed. Unknown top-level keys
+	// are not rejected: they're left out of it and only reported as warnings in
+	// the build output.
+	TsuruYamlData *TsuruYamlData `protobuf:"bytes,6,opt,name=tsuru_yaml_data,json=tsuruYamlData,proto3" json:"tsuru_yaml_data,omitempty"`
+	// ProcfileDerived indicates the Procfile was not found, so it was derived from
+	// the container image's entrypoint and command as a single web process.
+	ProcfileDerived bool `protobuf:"varint,7,opt,name=procfile_derived,json=procfileDerived,proto3" json:"procfile_derived,omitempty"`
+	// ProcfilePath is the path of the Procfile picked, if any.
+	ProcfilePath string `protobuf:"bytes,8,opt,name=procfile_path,json=procfilePath,proto3" json:"procfile_path,omitempty"`
+	// TsuruYamlPath is the path of the TsuruYAML picked, if any.
+	TsuruYamlPath string `protobuf:"bytes,9,opt,name=tsuru_yaml_path,json=tsuruYamlPath,proto3" json:"tsuru_yaml_path,omitempty"`
+}
+
+func (x *TsuruConfig) Reset() {
+	*x = TsuruConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruConfig) ProtoMessage() {}
+
+func (x *TsuruConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruConfig.ProtoReflect.Descriptor instead.
+func (*TsuruConfig) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TsuruConfig) GetProcfile() string {
+	if x != nil {
+		return x.Procfile
+	}
+	return ""
+}
+
+func (x *TsuruConfig) GetTsuruYaml() string {
+	if x != nil {
+		return x.TsuruYaml
+	}
+	return ""
+}
+
+func (x *TsuruConfig) GetImageConfig() *ContainerImageConfig {
+	if x != nil {
+		return x.ImageConfig
+	}
+	return nil
+}
+
+func (x *TsuruConfig) GetAttestations() []*Attestation {
+	if x != nil {
+		return x.Attestations
+	}
+	return nil
+}
+
+func (x *TsuruConfig) GetProcesses() []*TsuruProcess {
+	if x != nil {
+		return x.Processes
+	}
+	return nil
+}
+
+func (x *TsuruConfig) GetTsuruYamlData() *TsuruYamlData {
+	if x != nil {
+		return x.TsuruYamlData
+	}
+	return nil
+}
+
+func (x *TsuruConfig) GetProcfileDerived() bool {
+	if x != nil {
+		return x.ProcfileDerived
+	}
+	return false
+}
+
+func (x *TsuruConfig) GetProcfilePath() string {
+	if x != nil {
+		return x.ProcfilePath
+	}
+	return ""
+}
+
+func (x *TsuruConfig) GetTsuruYamlPath() string {
+	if x != nil {
+		return x.TsuruYamlPath
+	}
+	return ""
+}
+
+type TsuruProcess struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name is the process name (e.g. web).
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Command is the command line which starts the process.
+	Command string `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+}
+
+func (x *TsuruProcess) Reset() {
+	*x = TsuruProcess{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruProcess) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruProcess) ProtoMessage() {}
+
+func (x *TsuruProcess) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruProcess.ProtoReflect.Descriptor instead.
+func (*TsuruProcess) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TsuruProcess) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TsuruProcess) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+type TsuruYamlData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hooks       *TsuruYamlHooks            `protobuf:"bytes,1,opt,name=hooks,proto3" json:"hooks,omitempty"`
+	Healthcheck *TsuruYamlHealthcheck      `protobuf:"bytes,2,opt,name=healthcheck,proto3" json:"healthcheck,omitempty"`
+	Kubernetes  *TsuruYamlKubernetesConfig `protobuf:"bytes,3,opt,name=kubernetes,proto3" json:"kubernetes,omitempty"`
+}
+
+func (x *TsuruYamlData) Reset() {
+	*x = TsuruYamlData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruYamlData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruYamlData) ProtoMessage() {}
+
+func (x *TsuruYamlData) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruYamlData.ProtoReflect.Descriptor instead.
+func (*TsuruYamlData) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *TsuruYamlData) GetHooks() *TsuruYamlHooks {
+	if x != nil {
+		return x.Hooks
+	}
+	return nil
+}
+
+func (x *TsuruYamlData) GetHealthcheck() *TsuruYamlHealthcheck {
+	if x != nil {
+		return x.Healthcheck
+	}
+	return nil
+}
+
+func (x *TsuruYamlData) GetKubernetes() *TsuruYamlKubernetesConfig {
+	if x != nil {
+		return x.Kubernetes
+	}
+	return nil
+}
+
+type TsuruYamlHooks struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Restart *TsuruYamlRestartHooks `protobuf:"bytes,1,opt,name=restart,proto3" json:"restart,omitempty"`
+	Build   []string               `protobuf:"bytes,2,rep,name=build,proto3" json:"build,omitempty"`
+}
+
+func (x *TsuruYamlHooks) Reset() {
+	*x = TsuruYamlHooks{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruYamlHooks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruYamlHooks) ProtoMessage() {}
+
+func (x *TsuruYamlHooks) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruYamlHooks.ProtoReflect.Descriptor instead.
+func (*TsuruYamlHooks) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TsuruYamlHooks) GetRestart() *TsuruYamlRestartHooks {
+	if x != nil {
+		return x.Restart
+	}
+	return nil
+}
+
+func (x *TsuruYamlHooks) GetBuild() []string {
+	if x != nil {
+		return x.Build
+	}
+	return nil
+}
+
+type TsuruYamlRestartHooks struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Before []string `protobuf:"bytes,1,rep,name=before,proto3" json:"before,omitempty"`
+	After  []string `protobuf:"bytes,2,rep,name=after,proto3" json:"after,omitempty"`
+}
+
+func (x *TsuruYamlRestartHooks) Reset() {
+	*x = TsuruYamlRestartHooks{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruYamlRestartHooks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruYamlRestartHooks) ProtoMessage() {}
+
+func (x *TsuruYamlRestartHooks) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruYamlRestartHooks.ProtoReflect.Descriptor instead.
+func (*TsuruYamlRestartHooks) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TsuruYamlRestartHooks) GetBefore() []string {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *TsuruYamlRestartHooks) GetAfter() []string {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+type TsuruYamlHealthcheck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Headers              map[string]string `protobuf:"bytes,1,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Path                 string            `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Method               string            `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	Scheme               string            `protobuf:"bytes,4,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	Match                string            `protobuf:"bytes,5,opt,name=match,proto3" json:"match,omitempty"`
+	RouterBody           string            `protobuf:"bytes,6,opt,name=router_body,json=routerBody,proto3" json:"router_body,omitempty"`
+	Command              []string          `protobuf:"bytes,7,rep,name=command,proto3" json:"command,omitempty"`
+	Status               int32             `protobuf:"varint,8,opt,name=status,proto3" json:"status,omitempty"`
+	AllowedFailures      int32             `protobuf:"varint,9,opt,name=allowed_failures,json=allowedFailures,proto3" json:"allowed_failures,omitempty"`
+	IntervalSeconds      int32             `protobuf:"varint,10,opt,name=interval_seconds,json=intervalSeconds,proto3" json:"interval_seconds,omitempty"`
+	TimeoutSeconds       int32             `protobuf:"varint,11,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	DeployTimeoutSeconds int32             `protobuf:"varint,12,opt,name=deploy_timeout_seconds,json=deployTimeoutSeconds,proto3" json:"deploy_timeout_seconds,omitempty"`
+	UseInRouter          bool              `protobuf:"varint,13,opt,name=use_in_router,json=useInRouter,proto3" json:"use_in_router,omitempty"`
+	ForceRestart         bool              `protobuf:"varint,14,opt,name=force_restart,json=forceRestart,proto3" json:"force_restart,omitempty"`
+}
+
+func (x *TsuruYamlHealthcheck) Reset() {
+	*x = TsuruYamlHealthcheck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruYamlHealthcheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruYamlHealthcheck) ProtoMessage() {}
+
+func (x *TsuruYamlHealthcheck) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruYamlHealthcheck.ProtoReflect.Descriptor instead.
+func (*TsuruYamlHealthcheck) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TsuruYamlHealthcheck) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *TsuruYamlHealthcheck) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *TsuruYamlHealthcheck) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *TsuruYamlHealthcheck) GetScheme() string {
+	if x != nil {
+		return x.Scheme
+	}
+	return ""
+}
+
+func (x *TsuruYamlHealthcheck) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *TsuruYamlHealthcheck) GetRouterBody() string {
+	if x != nil {
+		return x.RouterBody
+	}
+	return ""
+}
+
+func (x *TsuruYamlHealthcheck) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *TsuruYamlHealthcheck) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *TsuruYamlHealthcheck) GetAllowedFailures() int32 {
+	if x != nil {
+		return x.AllowedFailures
+	}
+	return 0
+}
+
+func (x *TsuruYamlHealthcheck) GetIntervalSeconds() int32 {
+	if x != nil {
+		return x.IntervalSeconds
+	}
+	return 0
+}
+
+func (x *TsuruYamlHealthcheck) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *TsuruYamlHealthcheck) GetDeployTimeoutSeconds() int32 {
+	if x != nil {
+		return x.DeployTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *TsuruYamlHealthcheck) GetUseInRouter() bool {
+	if x != nil {
+		return x.UseInRouter
+	}
+	return false
+}
+
+func (x *TsuruYamlHealthcheck) GetForceRestart() bool {
+	if x != nil {
+		return x.ForceRestart
+	}
+	return false
+}
+
+type TsuruYamlKubernetesConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Groups map[string]*TsuruYamlKubernetesGroup `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *TsuruYamlKubernetesConfig) Reset() {
+	*x = TsuruYamlKubernetesConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruYamlKubernetesConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruYamlKubernetesConfig) ProtoMessage() {}
+
+func (x *TsuruYamlKubernetesConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TsuruYamlKubernetesConfig.ProtoReflect.Descriptor instead.
+func (*TsuruYamlKubernetesConfig) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *TsuruYamlKubernetesConfig) GetGroups() map[string]*TsuruYamlKubernetesGroup {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+type TsuruYamlKubernetesGroup struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Processes are indexed by process name.
+	Processes map[string]*TsuruYamlKubernetesProcessConfig `protobuf:"bytes,1,rep,name=processes,proto3" json:"processes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *TsuruYamlKubernetesGroup) Reset() {
+	*x = TsuruYamlKubernetesGroup{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TsuruYamlKubernetesGroup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TsuruYamlKubernetesGroup) ProtoMessage() {}
+
+func (x *TsuruYamlKubernetesGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (x *PushOptions) GetInsecureRegistry() bool {
+// Deprecated: Use TsuruYamlKubernetesGroup.ProtoReflect.Descriptor instead.
+func (*TsuruYamlKubernetesGroup) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *TsuruYamlKubernetesGroup) GetProcesses() map[string]*TsuruYamlKubernetesProcessConfig {
 	if x != nil {
-		return x.InsecureRegistry
+		return x.Processes
 	}
-	return false
+	return nil
 }
 
-type ContainerImageConfig struct {
+type TsuruYamlKubernetesProcessConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Entrypoint   []string `protobuf:"bytes,1,rep,name=entrypoint,proto3" json:"entrypoint,omitempty"`
-	Cmd          []string `protobuf:"bytes,2,rep,name=cmd,proto3" json:"cmd,omitempty"`
-	ExposedPorts []string `protobuf:"bytes,3,rep,name=exposed_ports,json=exposedPorts,proto3" json:"exposed_ports,omitempty"`
-	WorkingDir   string   `protobuf:"bytes,4,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`
+	Ports []*TsuruYamlKubernetesProcessPortConfig `protobuf:"bytes,1,rep,name=ports,proto3" json:"ports,omitempty"`
 }
 
-func (x *ContainerImageConfig) Reset() {
-	*x = ContainerImageConfig{}
+func (x *TsuruYamlKubernetesProcessConfig) Reset() {
+	*x = TsuruYamlKubernetesProcessConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[5]
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ContainerImageConfig) String() string {
+func (x *TsuruYamlKubernetesProcessConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ContainerImageConfig) ProtoMessage() {}
+func (*TsuruYamlKubernetesProcessConfig) ProtoMessage() {}
 
-func (x *ContainerImageConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[5]
+func (x *TsuruYamlKubernetesProcessConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -498,69 +2104,46 @@ func (x *ContainerImageConfig) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ContainerImageConfig.ProtoReflect.Descriptor instead.
-func (*ContainerImageConfig) Descriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{5}
-}
-
-func (x *ContainerImageConfig) GetEntrypoint() []string {
-	if x != nil {
-		return x.Entrypoint
-	}
-	return nil
-}
-
-func (x *ContainerImageConfig) GetCmd() []string {
-	if x != nil {
-		return x.Cmd
-	}
-	return nil
+// Deprecated: Use TsuruYamlKubernetesProcessConfig.ProtoReflect.Descriptor instead.
+func (*TsuruYamlKubernetesProcessConfig) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *ContainerImageConfig) GetExposedPorts() []string {
+func (x *TsuruYamlKubernetesProcessConfig) GetPorts() []*TsuruYamlKubernetesProcessPortConfig {
 	if x != nil {
-		return x.ExposedPorts
+		return x.Ports
 	}
 	return nil
 }
 
-func (x *ContainerImageConfig) GetWorkingDir() string {
-	if x != nil {
-		return x.WorkingDir
-	}
-	return ""
-}
-
-type TsuruConfig struct {
+type TsuruYamlKubernetesProcessPortConfig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Procfile definition found during the build.
-	Procfile string `protobuf:"bytes,1,opt,name=procfile,proto3" json:"procfile,omitempty"`
-	// TsuruYAML definition found during the build.
-	TsuruYaml string `protobuf:"bytes,2,opt,name=tsuru_yaml,json=tsuruYaml,proto3" json:"tsuru_yaml,omitempty"`
-	// ContainerImageConfig found in the container image registry.
-	ImageConfig *ContainerImageConfig `protobuf:"bytes,3,opt,name=image_config,json=imageConfig,proto3" json:"image_config,omitempty"`
+	Name       string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Protocol   string `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Port       int32  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	TargetPort int32  `protobuf:"varint,4,opt,name=target_port,json=targetPort,proto3" json:"target_port,omitempty"`
 }
 
-func (x *TsuruConfig) Reset() {
-	*x = TsuruConfig{}
+func (x *TsuruYamlKubernetesProcessPortConfig) Reset() {
+	*x = TsuruYamlKubernetesProcessPortConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[6]
+		mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TsuruConfig) String() string {
+func (x *TsuruYamlKubernetesProcessPortConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TsuruConfig) ProtoMessage() {}
+func (*TsuruYamlKubernetesProcessPortConfig) ProtoMessage() {}
 
-func (x *TsuruConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[6]
+func (x *TsuruYamlKubernetesProcessPortConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -571,30 +2154,37 @@ func (x *TsuruConfig) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TsuruConfig.ProtoReflect.Descriptor instead.
-func (*TsuruConfig) Descriptor() ([]byte, []int) {
-	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use TsuruYamlKubernetesProcessPortConfig.ProtoReflect.Descriptor instead.
+func (*TsuruYamlKubernetesProcessPortConfig) Descriptor() ([]byte, []int) {
+	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *TsuruConfig) GetProcfile() string {
+func (x *TsuruYamlKubernetesProcessPortConfig) GetName() string {
 	if x != nil {
-		return x.Procfile
+		return x.Name
 	}
 	return ""
 }
 
-func (x *TsuruConfig) GetTsuruYaml() string {
+func (x *TsuruYamlKubernetesProcessPortConfig) GetProtocol() string {
 	if x != nil {
-		return x.TsuruYaml
+		return x.Protocol
 	}
 	return ""
 }
 
-func (x *TsuruConfig) GetImageConfig() *ContainerImageConfig {
+func (x *TsuruYamlKubernetesProcessPortConfig) GetPort() int32 {
 	if x != nil {
-		return x.ImageConfig
+		return x.Port
 	}
-	return nil
+	return 0
+}
+
+func (x *TsuruYamlKubernetesProcessPortConfig) GetTargetPort() int32 {
+	if x != nil {
+		return x.TargetPort
+	}
+	return 0
 }
 
 var File_pkg_build_grpc_build_v1_build_service_proto protoreflect.FileDescriptor
@@ -603,108 +2193,424 @@ var file_pkg_build_grpc_build_v1_build_service_proto_rawDesc = []byte{
 	0x0a, 0x2b, 0x70, 0x6b, 0x67, 0x2f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x2f, 0x67, 0x72, 0x70, 0x63,
 	0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f,
 	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x67,
-	0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x22, 0xec, 0x02, 0x0a,
-	0x0c, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a,
-	0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c,
-	0x64, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x29, 0x0a, 0x03, 0x61,
-	0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
-	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x41, 0x70,
-	0x70, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x38, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f,
-	0x72, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
-	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x50, 0x6c,
-	0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d,
-	0x12, 0x21, 0x0a, 0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49, 0x6d,
-	0x61, 0x67, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52,
-	0x11, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6d, 0x61, 0x67,
-	0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69,
-	0x6e, 0x65, 0x72, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x43,
-	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x3d, 0x0a, 0x0c,
-	0x70, 0x75, 0x73, 0x68, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0a, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f,
-	0x76, 0x31, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0b,
-	0x70, 0x75, 0x73, 0x68, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x72, 0x0a, 0x0d, 0x42,
-	0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x06,
-	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06,
-	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x3f, 0x0a, 0x0c, 0x74, 0x73, 0x75, 0x72, 0x75, 0x5f,
-	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
-	0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75,
-	0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x48, 0x00, 0x52, 0x0b, 0x74, 0x73, 0x75, 0x72,
-	0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x42, 0x06, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22,
-	0x9b, 0x01, 0x0a, 0x08, 0x54, 0x73, 0x75, 0x72, 0x75, 0x41, 0x70, 0x70, 0x12, 0x12, 0x0a, 0x04,
+	0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa6, 0x07,
+	0x0a, 0x0c, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c,
+	0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69,
+	0x6c, 0x64, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x29, 0x0a, 0x03,
+	0x61, 0x70, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x41,
+	0x70, 0x70, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x38, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66,
+	0x6f, 0x72, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x50,
+	0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72,
+	0x6d, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x12, 0x2d, 0x0a, 0x12, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x11, 0x64, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6d, 0x61,
+	0x67, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x24, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61,
+	0x69, 0x6e, 0x65, 0x72, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x3d, 0x0a,
+	0x0c, 0x70, 0x75, 0x73, 0x68, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64,
+	0x5f, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x73, 0x68, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x0b, 0x70, 0x75, 0x73, 0x68, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x43, 0x0a, 0x0e,
+	0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x5f, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c,
+	0x64, 0x5f, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x41, 0x72, 0x63, 0x68, 0x69,
+	0x76, 0x65, 0x52, 0x0d, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x12, 0x45, 0x0a, 0x0c, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62,
+	0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0c, 0x61, 0x74, 0x74, 0x65,
+	0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x50, 0x0a, 0x13, 0x74, 0x73, 0x75, 0x72,
+	0x75, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69,
+	0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x11, 0x74, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x12, 0x46, 0x0a, 0x11, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x0f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x44, 0x61, 0x74, 0x65, 0x45, 0x70, 0x6f,
+	0x63, 0x68, 0x12, 0x3d, 0x0a, 0x0c, 0x63, 0x61, 0x63, 0x68, 0x65, 0x5f, 0x70, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x43, 0x61, 0x63, 0x68, 0x65, 0x50, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x52, 0x0b, 0x63, 0x61, 0x63, 0x68, 0x65, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x12, 0x40, 0x0a, 0x0b, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x68, 0x6f, 0x6f, 0x6b, 0x73,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75,
+	0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x48, 0x6f, 0x6f, 0x6b,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0a, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x48, 0x6f,
+	0x6f, 0x6b, 0x73, 0x12, 0x40, 0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x5f,
+	0x6d, 0x6f, 0x64, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x4f, 0x0a, 0x12, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x12, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x20, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76,
+	0x31, 0x2e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x11, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x6e, 0x0a, 0x10, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x48,
+	0x6f, 0x6f, 0x6b, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x65,
+	0x70, 0x61, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x74, 0x65, 0x70, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0d, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x65, 0x53, 0x74, 0x65, 0x70,
+	0x73, 0x12, 0x33, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x74,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x22, 0x72, 0x0a, 0x0d, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x12, 0x3f, 0x0a, 0x0c, 0x74, 0x73, 0x75, 0x72, 0x75, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62,
+	0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x48, 0x00, 0x52, 0x0b, 0x74, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x42, 0x06, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0xd2, 0x02, 0x0a, 0x0c, 0x42,
+	0x75, 0x69, 0x6c, 0x64, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x24, 0x0a, 0x0d, 0x63,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x66, 0x69, 0x6c,
+	0x65, 0x12, 0x3d, 0x0a, 0x0c, 0x74, 0x73, 0x75, 0x72, 0x75, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62,
+	0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x52, 0x0b, 0x74, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x68, 0x6f, 0x6f, 0x6b, 0x73, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x48, 0x6f, 0x6f, 0x6b,
+	0x73, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x5f, 0x66, 0x69, 0x6c,
+	0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78,
+	0x74, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x55, 0x0a, 0x0e, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42,
+	0x75, 0x69, 0x6c, 0x64, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x2e, 0x46, 0x72, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x64, 0x41, 0x74, 0x74, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0d,
+	0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x41, 0x74, 0x74, 0x72, 0x73, 0x1a, 0x40, 0x0a,
+	0x12, 0x46, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x41, 0x74, 0x74, 0x72, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0xf8, 0x02, 0x0a, 0x08, 0x54, 0x73, 0x75, 0x72, 0x75, 0x41, 0x70, 0x70, 0x12, 0x12, 0x0a, 0x04,
 	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
 	0x12, 0x3f, 0x0a, 0x08, 0x65, 0x6e, 0x76, 0x5f, 0x76, 0x61, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03,
 	0x28, 0x0b, 0x32, 0x24, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f,
 	0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x41, 0x70, 0x70, 0x2e, 0x45, 0x6e, 0x76, 0x56,
 	0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x76, 0x56, 0x61, 0x72,
-	0x73, 0x1a, 0x3a, 0x0a, 0x0c, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x23, 0x0a,
-	0x0d, 0x54, 0x73, 0x75, 0x72, 0x75, 0x50, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x12,
-	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x22, 0x54, 0x0a, 0x0b, 0x50, 0x75, 0x73, 0x68, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x07, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x69,
-	0x6e, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65, 0x5f, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65,
-	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x22, 0x8e, 0x01, 0x0a, 0x14, 0x43, 0x6f, 0x6e,
-	0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x70, 0x6f, 0x69, 0x6e,
-	0x74, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6d, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03,
-	0x63, 0x6d, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x70, 0x6f, 0x73, 0x65, 0x64, 0x5f, 0x70,
-	0x6f, 0x72, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78, 0x70, 0x6f,
-	0x73, 0x65, 0x64, 0x50, 0x6f, 0x72, 0x74, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x77, 0x6f, 0x72, 0x6b,
-	0x69, 0x6e, 0x67, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x77,
-	0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x44, 0x69, 0x72, 0x22, 0x90, 0x01, 0x0a, 0x0b, 0x54, 0x73,
-	0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f,
-	0x63, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x72, 0x6f,
-	0x63, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x73, 0x75, 0x72, 0x75, 0x5f, 0x79,
-	0x61, 0x6d, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x73, 0x75, 0x72, 0x75,
-	0x59, 0x61, 0x6d, 0x6c, 0x12, 0x46, 0x0a, 0x0c, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x63, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x67, 0x72, 0x70,
+	0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x4f, 0x0a,
+	0x0e, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x65, 0x6e, 0x76, 0x5f, 0x76, 0x61, 0x72, 0x73, 0x18,
+	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69,
+	0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x41, 0x70, 0x70, 0x2e, 0x42,
+	0x75, 0x69, 0x6c, 0x64, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x0c, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x12, 0x2d,
+	0x0a, 0x13, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x65, 0x6e, 0x76, 0x5f, 0x76, 0x61, 0x72, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x10, 0x62, 0x75, 0x69,
+	0x6c, 0x64, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x1a, 0x3a, 0x0a,
+	0x0c, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3f, 0x0a, 0x11, 0x42, 0x75, 0x69,
+	0x6c, 0x64, 0x45, 0x6e, 0x76, 0x56, 0x61, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x23, 0x0a, 0x0d, 0x54, 0x73,
+	0x75, 0x72, 0x75, 0x50, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22,
+	0x54, 0x0a, 0x0b, 0x50, 0x75, 0x73, 0x68, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x07, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x69, 0x6e, 0x73, 0x65,
+	0x63, 0x75, 0x72, 0x65, 0x5f, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x72, 0x65, 0x52, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x72, 0x79, 0x22, 0x39, 0x0a, 0x0d, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x41,
+	0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32,
+	0x35, 0x36, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36,
+	0x22, 0x78, 0x0a, 0x11, 0x54, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x53,
+	0x65, 0x61, 0x72, 0x63, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x69, 0x72, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x69, 0x72, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x74, 0x73, 0x75,
+	0x72, 0x75, 0x5f, 0x79, 0x61, 0x6d, 0x6c, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0e, 0x74, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4e, 0x61,
+	0x6d, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x6f, 0x63, 0x66, 0x69, 0x6c, 0x65, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x70, 0x72, 0x6f,
+	0x63, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x22, 0x67, 0x0a, 0x12, 0x41, 0x74,
+	0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x73, 0x62, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04,
+	0x73, 0x62, 0x6f, 0x6d, 0x12, 0x3d, 0x0a, 0x0a, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61,
+	0x6e, 0x63, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0a, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61,
+	0x6e, 0x63, 0x65, 0x22, 0x75, 0x0a, 0x0b, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x75,
+	0x62, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x73, 0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x44, 0x69, 0x67, 0x65, 0x73,
+	0x74, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x72, 0x65, 0x64, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x72, 0x65, 0x64,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x54, 0x79, 0x70, 0x65, 0x73, 0x22, 0xa4, 0x04, 0x0a, 0x14, 0x43,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x6e, 0x74, 0x72, 0x79, 0x70, 0x6f,
+	0x69, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6d, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x03, 0x63, 0x6d, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x78, 0x70, 0x6f, 0x73, 0x65, 0x64,
+	0x5f, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x78,
+	0x70, 0x6f, 0x73, 0x65, 0x64, 0x50, 0x6f, 0x72, 0x74, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x77, 0x6f,
+	0x72, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x77, 0x6f, 0x72, 0x6b, 0x69, 0x6e, 0x67, 0x44, 0x69, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x65,
+	0x6e, 0x76, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x12, 0x0a,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65,
+	0x72, 0x12, 0x47, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x2f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76,
+	0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x6f,
+	0x6c, 0x75, 0x6d, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x76, 0x6f, 0x6c,
+	0x75, 0x6d, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x6f, 0x70, 0x5f, 0x73, 0x69, 0x67,
+	0x6e, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x53,
+	0x69, 0x67, 0x6e, 0x61, 0x6c, 0x12, 0x4a, 0x0a, 0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x67, 0x72, 0x70,
 	0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61,
-	0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52,
-	0x0b, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2a, 0x9d, 0x03, 0x0a,
-	0x09, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x16, 0x42, 0x55,
-	0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
-	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x2b, 0x0a, 0x27, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f,
-	0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x57,
-	0x49, 0x54, 0x48, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x55, 0x50, 0x4c, 0x4f, 0x41,
-	0x44, 0x10, 0x01, 0x12, 0x2c, 0x0a, 0x28, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e,
-	0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x44, 0x45, 0x50, 0x4c, 0x4f, 0x59, 0x5f, 0x57, 0x49, 0x54,
-	0x48, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x55, 0x50, 0x4c, 0x4f, 0x41, 0x44, 0x10,
-	0x01, 0x12, 0x2d, 0x0a, 0x29, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f,
-	0x41, 0x50, 0x50, 0x5f, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43,
-	0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x49, 0x4d, 0x41, 0x47, 0x45, 0x10, 0x02,
-	0x12, 0x2e, 0x0a, 0x2a, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x41,
-	0x50, 0x50, 0x5f, 0x44, 0x45, 0x50, 0x4c, 0x4f, 0x59, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43,
-	0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x49, 0x4d, 0x41, 0x47, 0x45, 0x10, 0x02,
-	0x12, 0x2c, 0x0a, 0x28, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x41,
-	0x50, 0x50, 0x5f, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43, 0x4f,
-	0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10, 0x03, 0x12, 0x2d,
-	0x0a, 0x29, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50,
-	0x5f, 0x44, 0x45, 0x50, 0x4c, 0x4f, 0x59, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43, 0x4f, 0x4e,
-	0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10, 0x03, 0x12, 0x2c, 0x0a,
-	0x28, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x50, 0x4c, 0x41, 0x54,
-	0x46, 0x4f, 0x52, 0x4d, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49,
-	0x4e, 0x45, 0x52, 0x5f, 0x49, 0x4d, 0x41, 0x47, 0x45, 0x10, 0x05, 0x12, 0x2b, 0x0a, 0x27, 0x42,
-	0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x50, 0x4c, 0x41, 0x54, 0x46, 0x4f,
-	0x52, 0x4d, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45,
-	0x52, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10, 0x06, 0x1a, 0x02, 0x10, 0x01, 0x32, 0x4f, 0x0a, 0x05,
-	0x42, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x46, 0x0a, 0x05, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x1b,
-	0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42,
-	0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72,
-	0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c,
-	0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x37, 0x5a,
-	0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x73, 0x75, 0x72,
-	0x75, 0x2f, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x2d, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2f, 0x70,
-	0x6b, 0x67, 0x2f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75,
-	0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x52, 0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x68, 0x65, 0x63,
+	0x6b, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x37, 0x0a,
+	0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x67,
+	0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e,
+	0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x50, 0x6f, 0x72, 0x74, 0x52,
+	0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0xf3, 0x01, 0x0a, 0x19, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49,
+	0x6d, 0x61, 0x67, 0x65, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x12,
+	0x12, 0x0a, 0x04, 0x74, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x74,
+	0x65, 0x73, 0x74, 0x12, 0x35, 0x0a, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x08, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x33, 0x0a, 0x07, 0x74, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12,
+	0x3c, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x65, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x72, 0x65, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x44, 0x0a, 0x12, 0x43, 0x6f, 0x6e, 0x74, 0x61,
+	0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x70, 0x6f, 0x72,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0xc9, 0x03,
+	0x0a, 0x0b, 0x54, 0x73, 0x75, 0x72, 0x75, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1a, 0x0a,
+	0x08, 0x70, 0x72, 0x6f, 0x63, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x70, 0x72, 0x6f, 0x63, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x73, 0x75,
+	0x72, 0x75, 0x5f, 0x79, 0x61, 0x6d, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74,
+	0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x12, 0x46, 0x0a, 0x0c, 0x69, 0x6d, 0x61, 0x67,
+	0x65, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23,
+	0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x43,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x52, 0x0b, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x3e, 0x0a, 0x0c, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75,
+	0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0c, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x39, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64,
+	0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
+	0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x44, 0x0a, 0x0f, 0x74,
+	0x73, 0x75, 0x72, 0x75, 0x5f, 0x79, 0x61, 0x6d, 0x6c, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c,
+	0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x44, 0x61,
+	0x74, 0x61, 0x52, 0x0d, 0x74, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x44, 0x61, 0x74,
+	0x61, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x6f, 0x63, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x64, 0x65,
+	0x72, 0x69, 0x76, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x70, 0x72, 0x6f,
+	0x63, 0x66, 0x69, 0x6c, 0x65, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d,
+	0x70, 0x72, 0x6f, 0x63, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x63, 0x66, 0x69, 0x6c, 0x65, 0x50, 0x61, 0x74,
+	0x68, 0x12, 0x26, 0x0a, 0x0f, 0x74, 0x73, 0x75, 0x72, 0x75, 0x5f, 0x79, 0x61, 0x6d, 0x6c, 0x5f,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x73, 0x75, 0x72,
+	0x75, 0x59, 0x61, 0x6d, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x22, 0x3c, 0x0a, 0x0c, 0x54, 0x73, 0x75,
+	0x72, 0x75, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x22, 0xd5, 0x01, 0x0a, 0x0d, 0x54, 0x73, 0x75, 0x72,
+	0x75, 0x59, 0x61, 0x6d, 0x6c, 0x44, 0x61, 0x74, 0x61, 0x12, 0x33, 0x0a, 0x05, 0x68, 0x6f, 0x6f,
+	0x6b, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61,
+	0x6d, 0x6c, 0x48, 0x6f, 0x6f, 0x6b, 0x73, 0x52, 0x05, 0x68, 0x6f, 0x6f, 0x6b, 0x73, 0x12, 0x45,
+	0x0a, 0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64,
+	0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x48, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x52, 0x0b, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x48, 0x0a, 0x0a, 0x6b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65,
+	0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x67, 0x72, 0x70, 0x63,
+	0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59,
+	0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x52, 0x0a, 0x6b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x22,
+	0x66, 0x0a, 0x0e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x48, 0x6f, 0x6f, 0x6b,
+	0x73, 0x12, 0x3e, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x24, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f,
+	0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x52, 0x65, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x48, 0x6f, 0x6f, 0x6b, 0x73, 0x52, 0x07, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x22, 0x45, 0x0a, 0x15, 0x54, 0x73, 0x75, 0x72, 0x75,
+	0x59, 0x61, 0x6d, 0x6c, 0x52, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x48, 0x6f, 0x6f, 0x6b, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x06, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x66, 0x74, 0x65,
+	0x72, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x66, 0x74, 0x65, 0x72, 0x22, 0xc9,
+	0x04, 0x0a, 0x14, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x48, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x12, 0x4a, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x30, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61,
+	0x6d, 0x6c, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x2e, 0x48, 0x65,
+	0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x61, 0x74, 0x63, 0x68,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x1f, 0x0a,
+	0x0b, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x5f, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x42, 0x6f, 0x64, 0x79, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x29, 0x0a, 0x10, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x66, 0x61, 0x69, 0x6c,
+	0x75, 0x72, 0x65, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x61, 0x6c, 0x6c, 0x6f,
+	0x77, 0x65, 0x64, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x53,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0e, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12,
+	0x34, 0x0a, 0x16, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x14, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x75, 0x73, 0x65, 0x5f, 0x69, 0x6e, 0x5f,
+	0x72, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x75, 0x73,
+	0x65, 0x49, 0x6e, 0x52, 0x6f, 0x75, 0x74, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x6f, 0x72,
+	0x63, 0x65, 0x5f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0c, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x52, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x1a, 0x3a,
+	0x0a, 0x0c, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xcd, 0x01, 0x0a, 0x19, 0x54,
+	0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74,
+	0x65, 0x73, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x4c, 0x0a, 0x06, 0x67, 0x72, 0x6f, 0x75,
+	0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x34, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f,
+	0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61,
+	0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06,
+	0x67, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x1a, 0x62, 0x0a, 0x0b, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x3d, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75,
+	0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c,
+	0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xdf, 0x01, 0x0a, 0x18, 0x54,
+	0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74,
+	0x65, 0x73, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x54, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65,
+	0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x36, 0x2e, 0x67, 0x72, 0x70,
+	0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x54, 0x73, 0x75, 0x72, 0x75,
+	0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x47, 0x72,
+	0x6f, 0x75, 0x70, 0x2e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x1a, 0x6d, 0x0a,
+	0x0e, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x45, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x2f, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31,
+	0x2e, 0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e,
+	0x65, 0x74, 0x65, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x6d, 0x0a, 0x20,
+	0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65,
+	0x74, 0x65, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x12, 0x49, 0x0a, 0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x33, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e,
+	0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65,
+	0x74, 0x65, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x50, 0x6f, 0x72, 0x74, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x52, 0x05, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x22, 0x8b, 0x01, 0x0a, 0x24,
+	0x54, 0x73, 0x75, 0x72, 0x75, 0x59, 0x61, 0x6d, 0x6c, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65,
+	0x74, 0x65, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x50, 0x6f, 0x72, 0x74, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x2a, 0x78, 0x0a, 0x0c, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1d, 0x0a, 0x19, 0x50, 0x52, 0x4f,
+	0x47, 0x52, 0x45, 0x53, 0x53, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x47,
+	0x52, 0x45, 0x53, 0x53, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x50, 0x4c, 0x41, 0x49, 0x4e, 0x10,
+	0x01, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x52, 0x4f, 0x47, 0x52, 0x45, 0x53, 0x53, 0x5f, 0x4d, 0x4f,
+	0x44, 0x45, 0x5f, 0x54, 0x54, 0x59, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x50, 0x52, 0x4f, 0x47,
+	0x52, 0x45, 0x53, 0x53, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x52, 0x41, 0x57, 0x4a, 0x53, 0x4f,
+	0x4e, 0x10, 0x03, 0x2a, 0x73, 0x0a, 0x11, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x47,
+	0x52, 0x45, 0x53, 0x53, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f, 0x4e,
+	0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a, 0x50, 0x52, 0x4f, 0x47, 0x52, 0x45, 0x53,
+	0x53, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f, 0x45, 0x4c, 0x41, 0x50,
+	0x53, 0x45, 0x44, 0x10, 0x01, 0x12, 0x21, 0x0a, 0x1d, 0x50, 0x52, 0x4f, 0x47, 0x52, 0x45, 0x53,
+	0x53, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x5f, 0x57, 0x41, 0x4c, 0x4c,
+	0x5f, 0x43, 0x4c, 0x4f, 0x43, 0x4b, 0x10, 0x02, 0x2a, 0x76, 0x0a, 0x0b, 0x43, 0x61, 0x63, 0x68,
+	0x65, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1c, 0x0a, 0x18, 0x43, 0x41, 0x43, 0x48, 0x45,
+	0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x41, 0x43, 0x48, 0x45, 0x5f, 0x50,
+	0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x41, 0x4c, 0x57, 0x41, 0x59, 0x53, 0x10, 0x01, 0x12, 0x18,
+	0x0a, 0x14, 0x43, 0x41, 0x43, 0x48, 0x45, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x43,
+	0x4f, 0x4e, 0x54, 0x45, 0x4e, 0x54, 0x10, 0x02, 0x12, 0x16, 0x0a, 0x12, 0x43, 0x41, 0x43, 0x48,
+	0x45, 0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x4e, 0x45, 0x56, 0x45, 0x52, 0x10, 0x03,
+	0x2a, 0x9d, 0x03, 0x0a, 0x09, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x1a,
+	0x0a, 0x16, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x2b, 0x0a, 0x27, 0x42, 0x55,
+	0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x42, 0x55, 0x49,
+	0x4c, 0x44, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x55,
+	0x50, 0x4c, 0x4f, 0x41, 0x44, 0x10, 0x01, 0x12, 0x2c, 0x0a, 0x28, 0x42, 0x55, 0x49, 0x4c, 0x44,
+	0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x44, 0x45, 0x50, 0x4c, 0x4f, 0x59,
+	0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x55, 0x50, 0x4c,
+	0x4f, 0x41, 0x44, 0x10, 0x01, 0x12, 0x2d, 0x0a, 0x29, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b,
+	0x49, 0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x57, 0x49,
+	0x54, 0x48, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x49, 0x4d, 0x41,
+	0x47, 0x45, 0x10, 0x02, 0x12, 0x2e, 0x0a, 0x2a, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49,
+	0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x44, 0x45, 0x50, 0x4c, 0x4f, 0x59, 0x5f, 0x57, 0x49,
+	0x54, 0x48, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x49, 0x4d, 0x41,
+	0x47, 0x45, 0x10, 0x02, 0x12, 0x2c, 0x0a, 0x28, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49,
+	0x4e, 0x44, 0x5f, 0x41, 0x50, 0x50, 0x5f, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x57, 0x49, 0x54,
+	0x48, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x46, 0x49, 0x4c, 0x45,
+	0x10, 0x03, 0x12, 0x2d, 0x0a, 0x29, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44,
+	0x5f, 0x41, 0x50, 0x50, 0x5f, 0x44, 0x45, 0x50, 0x4c, 0x4f, 0x59, 0x5f, 0x57, 0x49, 0x54, 0x48,
+	0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10,
+	0x03, 0x12, 0x2c, 0x0a, 0x28, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f,
+	0x50, 0x4c, 0x41, 0x54, 0x46, 0x4f, 0x52, 0x4d, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43, 0x4f,
+	0x4e, 0x54, 0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x49, 0x4d, 0x41, 0x47, 0x45, 0x10, 0x05, 0x12,
+	0x2b, 0x0a, 0x27, 0x42, 0x55, 0x49, 0x4c, 0x44, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x50, 0x4c,
+	0x41, 0x54, 0x46, 0x4f, 0x52, 0x4d, 0x5f, 0x57, 0x49, 0x54, 0x48, 0x5f, 0x43, 0x4f, 0x4e, 0x54,
+	0x41, 0x49, 0x4e, 0x45, 0x52, 0x5f, 0x46, 0x49, 0x4c, 0x45, 0x10, 0x06, 0x1a, 0x02, 0x10, 0x01,
+	0x2a, 0x81, 0x01, 0x0a, 0x0e, 0x50, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x63, 0x65, 0x4d,
+	0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x1b, 0x50, 0x52, 0x4f, 0x56, 0x45, 0x4e, 0x41, 0x4e, 0x43,
+	0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x1c, 0x0a, 0x18, 0x50, 0x52, 0x4f, 0x56, 0x45, 0x4e, 0x41, 0x4e,
+	0x43, 0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x44, 0x49, 0x53, 0x41, 0x42, 0x4c, 0x45, 0x44,
+	0x10, 0x01, 0x12, 0x17, 0x0a, 0x13, 0x50, 0x52, 0x4f, 0x56, 0x45, 0x4e, 0x41, 0x4e, 0x43, 0x45,
+	0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x4d, 0x49, 0x4e, 0x10, 0x02, 0x12, 0x17, 0x0a, 0x13, 0x50,
+	0x52, 0x4f, 0x56, 0x45, 0x4e, 0x41, 0x4e, 0x43, 0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x4d,
+	0x41, 0x58, 0x10, 0x03, 0x32, 0x9b, 0x01, 0x0a, 0x05, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x46,
+	0x0a, 0x05, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62,
+	0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c,
+	0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x4a, 0x0a, 0x0c, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75,
+	0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64,
+	0x5f, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x50, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77,
+	0x22, 0x00, 0x42, 0x37, 0x5a, 0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x74, 0x73, 0x75, 0x72, 0x75, 0x2f, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x2d, 0x61, 0x67,
+	0x65, 0x6e, 0x74, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x2f, 0x67, 0x72,
+	0x70, 0x63, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x5f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -719,34 +2625,97 @@ func file_pkg_build_grpc_build_v1_build_service_proto_rawDescGZIP() []byte {
 	return file_pkg_build_grpc_build_v1_build_service_proto_rawDescData
 }
 
-var file_pkg_build_grpc_build_v1_build_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pkg_build_grpc_build_v1_build_service_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_pkg_build_grpc_build_v1_build_service_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_pkg_build_grpc_build_v1_build_service_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_pkg_build_grpc_build_v1_build_service_proto_goTypes = []interface{}{
-	(BuildKind)(0),               // 0: grpc_build_v1.BuildKind
-	(*BuildRequest)(nil),         // 1: grpc_build_v1.BuildRequest
-	(*BuildResponse)(nil),        // 2: grpc_build_v1.BuildResponse
-	(*TsuruApp)(nil),             // 3: grpc_build_v1.TsuruApp
-	(*TsuruPlatform)(nil),        // 4: grpc_build_v1.TsuruPlatform
-	(*PushOptions)(nil),          // 5: grpc_build_v1.PushOptions
-	(*ContainerImageConfig)(nil), // 6: grpc_build_v1.ContainerImageConfig
-	(*TsuruConfig)(nil),          // 7: grpc_build_v1.TsuruConfig
-	nil,                          // 8: grpc_build_v1.TsuruApp.EnvVarsEntry
+	(ProgressMode)(0),                            // 0: grpc_build_v1.ProgressMode
+	(ProgressTimestamp)(0),                       // 1: grpc_build_v1.ProgressTimestamp
+	(CachePolicy)(0),                             // 2: grpc_build_v1.CachePolicy
+	(BuildKind)(0),                               // 3: grpc_build_v1.BuildKind
+	(ProvenanceMode)(0),                          // 4: grpc_build_v1.ProvenanceMode
+	(*BuildRequest)(nil),                         // 5: grpc_build_v1.BuildRequest
+	(*BuildHookOptions)(nil),                     // 6: grpc_build_v1.BuildHookOptions
+	(*BuildResponse)(nil),                        // 7: grpc_build_v1.BuildResponse
+	(*BuildPreview)(nil),                         // 8: grpc_build_v1.BuildPreview
+	(*TsuruApp)(nil),                             // 9: grpc_build_v1.TsuruApp
+	(*TsuruPlatform)(nil),                        // 10: grpc_build_v1.TsuruPlatform
+	(*PushOptions)(nil),                          // 11: grpc_build_v1.PushOptions
+	(*RemoteArchive)(nil),                        // 12: grpc_build_v1.RemoteArchive
+	(*TsuruConfigSearch)(nil),                    // 13: grpc_build_v1.TsuruConfigSearch
+	(*AttestationOptions)(nil),                   // 14: grpc_build_v1.AttestationOptions
+	(*Attestation)(nil),                          // 15: grpc_build_v1.Attestation
+	(*ContainerImageConfig)(nil),                 // 16: grpc_build_v1.ContainerImageConfig
+	(*ContainerImageHealthcheck)(nil),            // 17: grpc_build_v1.ContainerImageHealthcheck
+	(*ContainerImagePort)(nil),                   // 18: grpc_build_v1.ContainerImagePort
+	(*TsuruConfig)(nil),                          // 19: grpc_build_v1.TsuruConfig
+	(*TsuruProcess)(nil),                         // 20: grpc_build_v1.TsuruProcess
+	(*TsuruYamlData)(nil),                        // 21: grpc_build_v1.TsuruYamlData
+	(*TsuruYamlHooks)(nil),                       // 22: grpc_build_v1.TsuruYamlHooks
+	(*TsuruYamlRestartHooks)(nil),                // 23: grpc_build_v1.TsuruYamlRestartHooks
+	(*TsuruYamlHealthcheck)(nil),                 // 24: grpc_build_v1.TsuruYamlHealthcheck
+	(*TsuruYamlKubernetesConfig)(nil),            // 25: grpc_build_v1.TsuruYamlKubernetesConfig
+	(*TsuruYamlKubernetesGroup)(nil),             // 26: grpc_build_v1.TsuruYamlKubernetesGroup
+	(*TsuruYamlKubernetesProcessConfig)(nil),     // 27: grpc_build_v1.TsuruYamlKubernetesProcessConfig
+	(*TsuruYamlKubernetesProcessPortConfig)(nil), // 28: grpc_build_v1.TsuruYamlKubernetesProcessPortConfig
+	nil,                           // 29: grpc_build_v1.BuildPreview.FrontendAttrsEntry
+	nil,                           // 30: grpc_build_v1.TsuruApp.EnvVarsEntry
+	nil,                           // 31: grpc_build_v1.TsuruApp.BuildEnvVarsEntry
+	nil,                           // 32: grpc_build_v1.ContainerImageConfig.LabelsEntry
+	nil,                           // 33: grpc_build_v1.TsuruYamlHealthcheck.HeadersEntry
+	nil,                           // 34: grpc_build_v1.TsuruYamlKubernetesConfig.GroupsEntry
+	nil,                           // 35: grpc_build_v1.TsuruYamlKubernetesGroup.ProcessesEntry
+	(*timestamppb.Timestamp)(nil), // 36: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 37: google.protobuf.Duration
 }
 var file_pkg_build_grpc_build_v1_build_service_proto_depIdxs = []int32{
-	0, // 0: grpc_build_v1.BuildRequest.kind:type_name -> grpc_build_v1.BuildKind
-	3, // 1: grpc_build_v1.BuildRequest.app:type_name -> grpc_build_v1.TsuruApp
-	4, // 2: grpc_build_v1.BuildRequest.platform:type_name -> grpc_build_v1.TsuruPlatform
-	5, // 3: grpc_build_v1.BuildRequest.push_options:type_name -> grpc_build_v1.PushOptions
-	7, // 4: grpc_build_v1.BuildResponse.tsuru_config:type_name -> grpc_build_v1.TsuruConfig
-	8, // 5: grpc_build_v1.TsuruApp.env_vars:type_name -> grpc_build_v1.TsuruApp.EnvVarsEntry
-	6, // 6: grpc_build_v1.TsuruConfig.image_config:type_name -> grpc_build_v1.ContainerImageConfig
-	1, // 7: grpc_build_v1.Build.Build:input_type -> grpc_build_v1.BuildRequest
-	2, // 8: grpc_build_v1.Build.Build:output_type -> grpc_build_v1.BuildResponse
-	8, // [8:9] is the sub-list for method output_type
-	7, // [7:8] is the sub-list for method input_type
-	7, // [7:7] is the sub-list for extension type_name
-	7, // [7:7] is the sub-list for extension extendee
-	0, // [0:7] is the sub-list for field type_name
+	3,  // 0: grpc_build_v1.BuildRequest.kind:type_name -> grpc_build_v1.BuildKind
+	9,  // 1: grpc_build_v1.BuildRequest.app:type_name -> grpc_build_v1.TsuruApp
+	10, // 2: grpc_build_v1.BuildRequest.platform:type_name -> grpc_build_v1.TsuruPlatform
+	11, // 3: grpc_build_v1.BuildRequest.push_options:type_name -> grpc_build_v1.PushOptions
+	12, // 4: grpc_build_v1.BuildRequest.remote_archive:type_name -> grpc_build_v1.RemoteArchive
+	14, // 5: grpc_build_v1.BuildRequest.attestations:type_name -> grpc_build_v1.AttestationOptions
+	13, // 6: grpc_build_v1.BuildRequest.tsuru_config_search:type_name -> grpc_build_v1.TsuruConfigSearch
+	36, // 7: grpc_build_v1.BuildRequest.source_date_epoch:type_name -> google.protobuf.Timestamp
+	2,  // 8: grpc_build_v1.BuildRequest.cache_policy:type_name -> grpc_build_v1.CachePolicy
+	6,  // 9: grpc_build_v1.BuildRequest.build_hooks:type_name -> grpc_build_v1.BuildHookOptions
+	0,  // 10: grpc_build_v1.BuildRequest.progress_mode:type_name -> grpc_build_v1.ProgressMode
+	1,  // 11: grpc_build_v1.BuildRequest.progress_timestamp:type_name -> grpc_build_v1.ProgressTimestamp
+	37, // 12: grpc_build_v1.BuildHookOptions.timeout:type_name -> google.protobuf.Duration
+	19, // 13: grpc_build_v1.BuildResponse.tsuru_config:type_name -> grpc_build_v1.TsuruConfig
+	19, // 14: grpc_build_v1.BuildPreview.tsuru_config:type_name -> grpc_build_v1.TsuruConfig
+	29, // 15: grpc_build_v1.BuildPreview.frontend_attrs:type_name -> grpc_build_v1.BuildPreview.FrontendAttrsEntry
+	30, // 16: grpc_build_v1.TsuruApp.env_vars:type_name -> grpc_build_v1.TsuruApp.EnvVarsEntry
+	31, // 17: grpc_build_v1.TsuruApp.build_env_vars:type_name -> grpc_build_v1.TsuruApp.BuildEnvVarsEntry
+	4,  // 18: grpc_build_v1.AttestationOptions.provenance:type_name -> grpc_build_v1.ProvenanceMode
+	32, // 19: grpc_build_v1.ContainerImageConfig.labels:type_name -> grpc_build_v1.ContainerImageConfig.LabelsEntry
+	17, // 20: grpc_build_v1.ContainerImageConfig.healthcheck:type_name -> grpc_build_v1.ContainerImageHealthcheck
+	18, // 21: grpc_build_v1.ContainerImageConfig.ports:type_name -> grpc_build_v1.ContainerImagePort
+	37, // 22: grpc_build_v1.ContainerImageHealthcheck.interval:type_name -> google.protobuf.Duration
+	37, // 23: grpc_build_v1.ContainerImageHealthcheck.timeout:type_name -> google.protobuf.Duration
+	37, // 24: grpc_build_v1.ContainerImageHealthcheck.start_period:type_name -> google.protobuf.Duration
+	16, // 25: grpc_build_v1.TsuruConfig.image_config:type_name -> grpc_build_v1.ContainerImageConfig
+	15, // 26: grpc_build_v1.TsuruConfig.attestations:type_name -> grpc_build_v1.Attestation
+	20, // 27: grpc_build_v1.TsuruConfig.processes:type_name -> grpc_build_v1.TsuruProcess
+	21, // 28: grpc_build_v1.TsuruConfig.tsuru_yaml_data:type_name -> grpc_build_v1.TsuruYamlData
+	22, // 29: grpc_build_v1.TsuruYamlData.hooks:type_name -> grpc_build_v1.TsuruYamlHooks
+	24, // 30: grpc_build_v1.TsuruYamlData.healthcheck:type_name -> grpc_build_v1.TsuruYamlHealthcheck
+	25, // 31: grpc_build_v1.TsuruYamlData.kubernetes:type_name -> grpc_build_v1.TsuruYamlKubernetesConfig
+	23, // 32: grpc_build_v1.TsuruYamlHooks.restart:type_name -> grpc_build_v1.TsuruYamlRestartHooks
+	33, // 33: grpc_build_v1.TsuruYamlHealthcheck.headers:type_name -> grpc_build_v1.TsuruYamlHealthcheck.HeadersEntry
+	34, // 34: grpc_build_v1.TsuruYamlKubernetesConfig.groups:type_name -> grpc_build_v1.TsuruYamlKubernetesConfig.GroupsEntry
+	35, // 35: grpc_build_v1.TsuruYamlKubernetesGroup.processes:type_name -> grpc_build_v1.TsuruYamlKubernetesGroup.ProcessesEntry
+	28, // 36: grpc_build_v1.TsuruYamlKubernetesProcessConfig.ports:type_name -> grpc_build_v1.TsuruYamlKubernetesProcessPortConfig
+	26, // 37: grpc_build_v1.TsuruYamlKubernetesConfig.GroupsEntry.value:type_name -> grpc_build_v1.TsuruYamlKubernetesGroup
+	27, // 38: grpc_build_v1.TsuruYamlKubernetesGroup.ProcessesEntry.value:type_name -> grpc_build_v1.TsuruYamlKubernetesProcessConfig
+	5,  // 39: grpc_build_v1.Build.Build:input_type -> grpc_build_v1.BuildRequest
+	5,  // 40: grpc_build_v1.Build.PreviewBuild:input_type -> grpc_build_v1.BuildRequest
+	7,  // 41: grpc_build_v1.Build.Build:output_type -> grpc_build_v1.BuildResponse
+	8,  // 42: grpc_build_v1.Build.PreviewBuild:output_type -> grpc_build_v1.BuildPreview
+	41, // [41:43] is the sub-list for method output_type
+	39, // [39:41] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
 }
 
 func init() { file_pkg_build_grpc_build_v1_build_service_proto_init() }
@@ -768,7 +2737,7 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 			}
 		}
 		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BuildResponse); i {
+			switch v := v.(*BuildHookOptions); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -780,7 +2749,7 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 			}
 		}
 		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TsuruApp); i {
+			switch v := v.(*BuildResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -792,7 +2761,7 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 			}
 		}
 		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TsuruPlatform); i {
+			switch v := v.(*BuildPreview); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -804,7 +2773,7 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 			}
 		}
 		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PushOptions); i {
+			switch v := v.(*TsuruApp); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -816,7 +2785,7 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 			}
 		}
 		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ContainerImageConfig); i {
+			switch v := v.(*TsuruPlatform); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -828,6 +2797,102 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 			}
 		}
 		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PushOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemoteArchive); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruConfigSearch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AttestationOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Attestation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContainerImageConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContainerImageHealthcheck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ContainerImagePort); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TsuruConfig); i {
 			case 0:
 				return &v.state
@@ -839,8 +2904,116 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 				return nil
 			}
 		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruProcess); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlHooks); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlRestartHooks); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlHealthcheck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlKubernetesConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlKubernetesGroup); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlKubernetesProcessConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TsuruYamlKubernetesProcessPortConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[1].OneofWrappers = []interface{}{
+	file_pkg_build_grpc_build_v1_build_service_proto_msgTypes[2].OneofWrappers = []interface{}{
 		(*BuildResponse_Output)(nil),
 		(*BuildResponse_TsuruConfig)(nil),
 	}
@@ -849,8 +3022,8 @@ func file_pkg_build_grpc_build_v1_build_service_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_build_grpc_build_v1_build_service_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   8,
+			NumEnums:      5,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
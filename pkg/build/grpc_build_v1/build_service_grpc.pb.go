@@ -24,6 +24,8 @@ const _ = grpc.SupportPackageIsVersion7
 type BuildClient interface {
 	// Builds (and pushes) container images.
 	Build(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (Build_BuildClient, error)
+	// Previews what a build would do, without solving nor pushing anything.
+	PreviewBuild(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (*BuildPreview, error)
 }
 
 type buildClient struct {
@@ -66,12 +68,23 @@ func (x *buildBuildClient) Recv() (*BuildResponse, error) {
 	return m, nil
 }
 
+func (c *buildClient) PreviewBuild(ctx context.Context, in *BuildRequest, opts ...grpc.CallOption) (*BuildPreview, error) {
+	out := new(BuildPreview)
+	err := c.cc.Invoke(ctx, "/grpc_build_v1.Build/PreviewBuild", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BuildServer is the server API for Build service.
 // All implementations must embed UnimplementedBuildServer
 // for forward compatibility
 type BuildServer interface {
 	// Builds (and pushes) container images.
 	Build(*BuildRequest, Build_BuildServer) error
+	// Previews what a build would do, without solving nor pushing anything.
+	PreviewBuild(context.Context, *BuildRequest) (*BuildPreview, error)
 	mustEmbedUnimplementedBuildServer()
 }
 
@@ -82,6 +95,9 @@ type UnimplementedBuildServer struct {
 func (UnimplementedBuildServer) Build(*BuildRequest, Build_BuildServer) error {
 	return status.Errorf(codes.Unimplemented, "method Build not implemented")
 }
+func (UnimplementedBuildServer) PreviewBuild(context.Context, *BuildRequest) (*BuildPreview, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewBuild not implemented")
+}
 func (UnimplementedBuildServer) mustEmbedUnimplementedBuildServer() {}
 
 // UnsafeBuildServer may be embedded to opt out of forward compatibility for this service.
@@ -116,13 +132,36 @@ func (x *buildBuildServer) Send(m *BuildResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Build_PreviewBuild_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BuildServer).PreviewBuild(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpc_build_v1.Build/PreviewBuild",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BuildServer).PreviewBuild(ctx, req.(*BuildRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Build_ServiceDesc is the grpc.ServiceDesc for Build service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var Build_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "grpc_build_v1.Build",
 	HandlerType: (*BuildServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PreviewBuild",
+			Handler:    _Build_PreviewBuild_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Build",
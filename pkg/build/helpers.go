@@ -7,246 +7,454 @@ package build
 import (
 	"archive/tar"
 	"bytes"
-	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"math"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/alessio/shellescape"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+	"github.com/tsuru/deploy-agent/pkg/util"
 )
 
-func IsTsuruYaml(filename string) bool {
-	baseName := filepath.Base(filename)
+// MaxTsuruConfigSize is the max size in bytes of either Procfile or tsuru.yaml.
+const MaxTsuruConfigSize = 1 << 20 // 1 MiB
 
-	for _, n := range TsuruYamlNames {
-		if n == baseName {
-			return true
-		}
-	}
-
-	return false
+func IsTsuruYaml(filename string) bool {
+	return TsuruConfigSearch{}.isTsuruYaml(filename)
 }
 
 type TsuruYamlCandidates map[string]string
 
 func (c TsuruYamlCandidates) Pick(workingDir string) string {
-	dirs := make([]string, 0, (len(TsuruConfigDirs) + 1))
-
-	if workingDir != "" {
-		dirs = append(dirs, workingDir) // added first to get higher precedence
-	}
-
-	dirs = append(dirs, TsuruConfigDirs...)
+	_, data := c.Find(workingDir, TsuruConfigSearch{})
+	return data
+}
 
-	for _, dir := range dirs {
-		for _, baseName := range TsuruYamlNames {
+// Find returns the path and content of the tsuru.yaml with higher precedence.
+func (c TsuruYamlCandidates) Find(workingDir string, s TsuruConfigSearch) (string, string) {
+	for _, dir := range s.dirs(workingDir) {
+		for _, baseName := range s.tsuruYamlNames() {
 			filename := filepath.Join(dir, baseName)
-			if s, found := c[filename]; found {
-				return s
+			if data, found := c[filename]; found {
+				return filename, data
 			}
 		}
 	}
 
-	return ""
+	return "", ""
 }
 
 func IsProcfile(filename string) bool {
-	return filepath.Base(filename) == ProcfileName
+	return TsuruConfigSearch{}.isProcfile(filename)
 }
 
 type ProcfileCandidates map[string]string
 
 func (c ProcfileCandidates) Pick(workingDir string) string {
-	dirs := make([]string, 0, (len(TsuruConfigDirs) + 1))
-
-	if workingDir != "" {
-		dirs = append(dirs, workingDir) // added first to get higher precedence
-	}
-
-	dirs = append(dirs, TsuruConfigDirs...)
+	_, data := c.Find(workingDir, TsuruConfigSearch{})
+	return data
+}
 
-	for _, dir := range dirs {
-		filename := filepath.Join(dir, ProcfileName)
-		if s, found := c[filename]; found {
-			return s
+// Find returns the path and content of the Procfile with higher precedence.
+func (c ProcfileCandidates) Find(workingDir string, s TsuruConfigSearch) (string, string) {
+	for _, dir := range s.dirs(workingDir) {
+		for _, baseName := range s.procfileNames() {
+			filename := filepath.Join(dir, baseName)
+			if data, found := c[filename]; found {
+				return filename, data
+			}
 		}
 	}
 
-	return ""
+	return "", ""
 }
 
-func ExtractTsuruAppFilesFromAppSourceContext(ctx context.Context, r io.Reader) (*pb.TsuruConfig, error) {
+// ExtractTsuruAppFilesFromAppSourceContext looks up both Procfile and
+// tsuru.yaml in the app source archive (any format supported by util.Archive),
+// whose files are deployed into the platform's working dir.
+func ExtractTsuruAppFilesFromAppSourceContext(ctx context.Context, a *util.Archive, workingDir string, s TsuruConfigSearch) (*pb.TsuruConfig, error) {
 	if err := ctx.Err(); err != nil { // context deadline exceeded
 		return nil, err
 	}
 
-	z, err := gzip.NewReader(r)
-	if err != nil { // not gzip file
-		return nil, fmt.Errorf("app source data must be a GZIP compressed file: %w", err)
-	}
-	defer z.Close()
-
-	t := tar.NewReader(z)
-
 	procfile := make(ProcfileCandidates)
 	tsuruYaml := make(TsuruYamlCandidates)
 
-	for {
-		h, err := t.Next()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to read next file in the tarball: %w", err)
-		}
-
+	err := a.Walk(ctx, func(h *tar.Header, r io.Reader) error {
 		if h.Typeflag != tar.TypeReg { // not a regular file, skipping...
-			continue
+			return nil
 		}
 
-		filename := filepath.Join(DefaultTsuruPlatformWorkingDir, h.Name) // nolint
-
-		if err = copyTsuruYamlToCandidate(filename, t, tsuruYaml); err != nil {
-			return nil, err
-		}
+		filename := filepath.Join(workingDir, h.Name) // nolint
 
-		if err = copyProcfileToCandidate(filename, t, procfile); err != nil {
-			return nil, err
-		}
+		return copyTsuruConfigsToCandidates(filename, r, s, procfile, tsuruYaml)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &pb.TsuruConfig{
-		Procfile:  procfile.Pick(DefaultTsuruPlatformWorkingDir),
-		TsuruYaml: tsuruYaml.Pick(DefaultTsuruPlatformWorkingDir),
-	}, nil
+	return newTsuruConfigFromCandidates(workingDir, s, procfile, tsuruYaml), nil
 }
 
-func ExtractTsuruAppFilesFromContainerImageTarball(ctx context.Context, r io.Reader, workingDir string) (*pb.TsuruConfig, error) {
+// ExtractTsuruAppFiles looks up both Procfile and tsuru.yaml (following the
+// search precedence) by reading only the candidate paths. readFile must
+// return an error matching fs.ErrNotExist when file is not found, and it
+// doesn't need to read more than MaxTsuruConfigSize+1 bytes.
+func ExtractTsuruAppFiles(ctx context.Context, workingDir string, s TsuruConfigSearch, readFile func(ctx context.Context, filename string) ([]byte, error)) (*pb.TsuruConfig, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	procfile := make(ProcfileCandidates)
-	tsuruYaml := make(TsuruYamlCandidates)
+	find := func(names []string) (string, string, error) {
+		for _, dir := range s.dirs(workingDir) {
+			for _, baseName := range names {
+				filename := filepath.Join(dir, baseName)
 
-	t := tar.NewReader(r)
-	for {
-		h, err := t.Next()
-		if errors.Is(err, io.EOF) {
-			break
-		}
+				data, err := readFile(ctx, filename)
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
 
-		if err != nil {
-			return nil, fmt.Errorf("failed to read next file in the tarball: %w", err)
-		}
+				if err != nil {
+					return "", "", fmt.Errorf("failed to read %s: %w", filename, err)
+				}
 
-		if h.Typeflag != tar.TypeReg {
-			continue
+				if len(data) > MaxTsuruConfigSize {
+					return "", "", tsuruConfigTooLarge(filename)
+				}
+
+				return filename, string(data), nil
+			}
 		}
 
-		filename := filepath.Join(string(filepath.Separator), h.Name) // nolint
+		return "", "", nil
+	}
 
-		if err = copyTsuruYamlToCandidate(filename, t, tsuruYaml); err != nil {
-			return nil, err
-		}
+	var tc pb.TsuruConfig
+	var err error
 
-		if err = copyProcfileToCandidate(filename, t, procfile); err != nil {
-			return nil, err
-		}
+	if tc.ProcfilePath, tc.Procfile, err = find(s.procfileNames()); err != nil {
+		return nil, err
+	}
+
+	if tc.TsuruYamlPath, tc.TsuruYaml, err = find(s.tsuruYamlNames()); err != nil {
+		return nil, err
 	}
 
-	return &pb.TsuruConfig{
-		Procfile:  procfile.Pick(workingDir),
-		TsuruYaml: tsuruYaml.Pick(workingDir),
-	}, nil
+	return &tc, nil
 }
 
-func copyTsuruYamlToCandidate(filename string, r io.Reader, dst TsuruYamlCandidates) error {
-	if !IsTsuruYaml(filename) {
+func newTsuruConfigFromCandidates(workingDir string, s TsuruConfigSearch, procfile ProcfileCandidates, tsuruYaml TsuruYamlCandidates) *pb.TsuruConfig {
+	var tc pb.TsuruConfig
+	tc.ProcfilePath, tc.Procfile = procfile.Find(workingDir, s)
+	tc.TsuruYamlPath, tc.TsuruYaml = tsuruYaml.Find(workingDir, s)
+	return &tc
+}
+
+func copyTsuruConfigsToCandidates(filename string, r io.Reader, s TsuruConfigSearch, procfile ProcfileCandidates, tsuruYaml TsuruYamlCandidates) error {
+	isTsuruYaml, isProcfile := s.isTsuruYaml(filename), s.isProcfile(filename)
+	if !isTsuruYaml && !isProcfile {
 		return nil
 	}
 
-	data, err := io.ReadAll(r)
+	data, err := io.ReadAll(io.LimitReader(r, MaxTsuruConfigSize+1))
 	if err != nil {
 		return err
 	}
 
-	dst[filename] = string(data)
-	return nil
-}
+	if len(data) > MaxTsuruConfigSize {
+		return tsuruConfigTooLarge(filename)
+	}
 
-func copyProcfileToCandidate(filename string, r io.Reader, dst ProcfileCandidates) error {
-	if !IsProcfile(filename) {
-		return nil
+	if isTsuruYaml {
+		tsuruYaml[filename] = string(data)
 	}
 
-	data, err := io.ReadAll(r)
-	if err != nil {
-		return err
+	if isProcfile {
+		procfile[filename] = string(data)
 	}
 
-	dst[filename] = string(data)
 	return nil
 }
 
+func tsuruConfigTooLarge(filename string) error {
+	return status.Errorf(codes.InvalidArgument, "%s exceeds the limit of %d bytes", filename, MaxTsuruConfigSize)
+}
+
+// ParseExposedPorts parses ports in the container image format (e.g. "8080/tcp", "53/udp")
+// sorted by port number and protocol. Ports without protocol are assumed TCP.
+func ParseExposedPorts(exposedPorts []string) ([]*pb.ContainerImagePort, error) {
+	var ports []*pb.ContainerImagePort
+
+	for _, ep := range exposedPorts {
+		p, proto, _ := strings.Cut(ep, "/")
+
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid exposed port %q", ep)
+		}
+
+		proto = strings.ToLower(proto)
+		if proto == "" {
+			proto = "tcp"
+		}
+
+		if proto != "tcp" && proto != "udp" && proto != "sctp" {
+			return nil, fmt.Errorf("invalid protocol of exposed port %q", ep)
+		}
+
+		ports = append(ports, &pb.ContainerImagePort{Port: int32(port), Protocol: proto})
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+
+		return ports[i].Protocol < ports[j].Protocol
+	})
+
+	return ports, nil
+}
+
+// BuildContainerfileParams is the data which Containerfile templates (either
+// built-in or operator-defined) are rendered with.
 type BuildContainerfileParams struct {
-	Image      string
-	BuildHooks []string
+	Image        string
+	BuildHooks   []string
+	PlatformName string
+	// SourceImageLabels are the labels of platform's container image.
+	SourceImageLabels map[string]string
+	// AppName keys the cache mount IDs (see CacheID), so apps can't read each
+	// other's caches. Cache dirs are ignored when it's empty.
+	AppName   string
+	CacheDirs []string
+	// CacheIDSecret is the agent's secret which cache mount IDs are derived
+	// from (see CacheMountID).
+	CacheIDSecret []byte
+	// Platform defaults to DefaultPlatformMetadata when it's empty.
+	Platform PlatformMetadata
+	// SeparateBuildHookSteps renders each build hook as its own RUN step
+	// (see BuildHookCommand), rather than chaining them into deploy's step.
+	SeparateBuildHookSteps bool
+	// BuildHookTimeout is the max duration of each build hook step, if any.
+	BuildHookTimeout time.Duration
+}
+
+// BuildHookCommand returns the shell command of i-th build hook as its own
+// step. It's tagged with the hook index, so failures can be traced back to
+// the hook (see NewBuildHookError).
+func (p BuildContainerfileParams) BuildHookCommand(i int) string {
+	var timeout string
+	if p.BuildHookTimeout > 0 {
+		timeout = fmt.Sprintf("timeout %d ", int64(math.Ceil(p.BuildHookTimeout.Seconds())))
+	}
+
+	return fmt.Sprintf("%s%ssh -lc %s", buildHookStepMarker(i), timeout, shellescape.Quote(p.BuildHooks[i]))
+}
+
+// CacheID returns the ID of app's cache mount of dir.
+func (p BuildContainerfileParams) CacheID(dir string) string {
+	return CacheMountID(p.CacheIDSecret, p.AppName, dir)
 }
 
 func BuildContainerfile(p BuildContainerfileParams) (string, error) {
+	return BuildContainerfileFromTemplate(containerfileTemplate, p)
+}
+
+// BuildContainerfileFromTemplate renders the template t, or the built-in
+// one when t is nil.
+func BuildContainerfileFromTemplate(t *template.Template, p BuildContainerfileParams) (string, error) {
+	if t == nil {
+		t = containerfileTemplate
+	}
+
+	if p.Platform == (PlatformMetadata{}) {
+		p.Platform = DefaultPlatformMetadata()
+	}
+
 	var w bytes.Buffer
-	if err := containerfileTemplate.Execute(&w, p); err != nil {
+	if err := t.Execute(&w, p); err != nil {
 		return "", err
 	}
 
 	return w.String(), nil
 }
 
+var containerfileTemplateFuncs = template.FuncMap{
+	"shellQuote": shellescape.Quote,
+}
+
 var containerfileTemplate = template.Must(template.New("containerfile").
-	Funcs(template.FuncMap{
-		"shellQuote": shellescape.Quote,
-	}).
+	Funcs(containerfileTemplateFuncs).
 	Parse(`
 FROM {{ .Image }}
 
-WORKDIR /home/application/current
+WORKDIR {{ .Platform.WorkingDir }}
 
-COPY ./application.tar.gz /home/application/archive.tar.gz
+COPY ./application.tar.gz {{ .Platform.ArchivePath }}
 
 ARG tsuru_deploy_cache=1
 
-RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+RUN {{ template "mounts" . }}
     [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
     && [ -f ~/.profile ] && . ~/.profile \
-    && /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz \
+    && {{ .Platform.DeployCommand }} archive file://{{ .Platform.ArchivePath }} \
+{{- if not .SeparateBuildHookSteps }}
 {{- range $_, $hook := .BuildHooks }}
     && { sh -lc {{ shellQuote . }}; } \
+{{- end }}
 {{- end }}
     && :
+{{- if .SeparateBuildHookSteps }}
+{{- range $i, $_ := .BuildHooks }}
+
+RUN {{ template "mounts" $ }}
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && {{ $.BuildHookCommand $i }}
+{{- end }}
+{{- end }}
+{{ define "mounts" -}}
+--mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid={{ .Platform.UID }},gid={{ .Platform.GID }} \
+{{- if .AppName }}
+{{- range $_, $dir := .CacheDirs }}
+    --mount=type=cache,id={{ $.CacheID $dir }},target={{ $dir }},uid={{ $.Platform.UID }},gid={{ $.Platform.GID }} \
+{{- end }}
+{{- end }}
+{{- end -}}
 `))
 
+const (
+	// DefaultOutputFlushInterval is the max time the build output is held
+	// before being sent to the client.
+	DefaultOutputFlushInterval = 100 * time.Millisecond
+	// DefaultOutputFlushSize is the max size in bytes of the build output
+	// held before being sent to the client.
+	DefaultOutputFlushSize = 32 << 10 // 32 KiB
+)
+
+// BuildResponseOutputWriter sends the build output to the client. Writes are
+// batched in a single message either every flush interval or when they reach
+// the flush size. When both are zero, every write is sent as is.
+//
+// Once a message fails to be sent (e.g. client went away), every later call
+// returns that error.
 type BuildResponseOutputWriter struct {
-	stream pb.Build_BuildServer
-	mu     sync.Mutex
+	stream        pb.Build_BuildServer
+	onError       func(error)
+	timer         *time.Timer
+	err           error
+	buf           bytes.Buffer
+	FlushInterval time.Duration
+	FlushSize     int
+	mu            sync.Mutex
+}
+
+// NewBuildResponseOutputWriter returns a writer with the default flush
+// interval and size. onError, if set, is called on the first failure to send
+// a message, so callers can stop the build (e.g. canceling its context).
+func NewBuildResponseOutputWriter(stream pb.Build_BuildServer, onError func(error)) *BuildResponseOutputWriter {
+	return &BuildResponseOutputWriter{
+		stream:        stream,
+		onError:       onError,
+		FlushInterval: DefaultOutputFlushInterval,
+		FlushSize:     DefaultOutputFlushSize,
+	}
 }
 
 func (w *BuildResponseOutputWriter) Write(p []byte) (int, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.err != nil {
+		return 0, w.err
+	}
+
 	if len(p) == 0 {
 		return 0, nil
 	}
 
-	return len(p), w.stream.Send(&pb.BuildResponse{Data: &pb.BuildResponse_Output{Output: string(p)}})
+	w.buf.Write(p)
+
+	if w.buf.Len() >= w.FlushSize || w.FlushInterval <= 0 {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.FlushInterval, func() { w.Flush() })
+	}
+
+	return len(p), nil
+}
+
+// Flush sends the held output, if any.
+func (w *BuildResponseOutputWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.flush()
+}
+
+// SendTsuruConfig sends the held output, if any, followed by tc.
+func (w *BuildResponseOutputWriter) SendTsuruConfig(tc *pb.TsuruConfig) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	return w.send(&pb.BuildResponse{Data: &pb.BuildResponse_TsuruConfig{TsuruConfig: tc}})
+}
+
+func (w *BuildResponseOutputWriter) flush() error {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+
+	if w.err != nil || w.buf.Len() == 0 {
+		return w.err
+	}
+
+	defer w.buf.Reset()
+
+	return w.send(&pb.BuildResponse{Data: &pb.BuildResponse_Output{Output: w.buf.String()}})
+}
+
+func (w *BuildResponseOutputWriter) send(r *pb.BuildResponse) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := w.stream.Send(r); err != nil {
+		w.err = err
+		if w.onError != nil {
+			w.onError(err)
+		}
+
+		return err
+	}
+
+	return nil
 }
 
 func (w *BuildResponseOutputWriter) Read(p []byte) (int, error) { // required to implement console.File
@@ -264,3 +472,59 @@ func (w *BuildResponseOutputWriter) Fd() uintptr { // required to implement cons
 func (w *BuildResponseOutputWriter) Name() string { // required to implement console.File
 	return ""
 }
+
+// outputWriter is the BuildResponseOutputWriter seen by builders: secrets are
+// redacted from the output (including the logs of rawjson progress mode) and,
+// optionally, every line is prefixed (e.g. with timestamps).
+type outputWriter struct {
+	*BuildResponseOutputWriter
+	redactor *util.RedactingWriter
+	rawJSON  *rawJSONWriter
+	w        io.Writer
+}
+
+func newOutputWriter(out *BuildResponseOutputWriter, secrets []string, prefix func() string, rawJSON bool) *outputWriter {
+	w := &outputWriter{BuildResponseOutputWriter: out, redactor: util.NewRedactingWriter(out, secrets)}
+
+	w.w = w.redactor
+	if rawJSON {
+		w.rawJSON = newRawJSONWriter(w.redactor, secrets)
+		w.w = w.rawJSON
+	}
+
+	if prefix != nil {
+		w.w = util.NewLinePrefixWriter(w.w, prefix)
+	}
+
+	return w
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+func (w *outputWriter) Flush() error {
+	if err := w.flushRedacted(); err != nil {
+		return err
+	}
+
+	return w.BuildResponseOutputWriter.Flush()
+}
+
+func (w *outputWriter) flushRedacted() error {
+	if w.rawJSON != nil {
+		if err := w.rawJSON.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return w.redactor.Flush()
+}
+
+func (w *outputWriter) SendTsuruConfig(tc *pb.TsuruConfig) error {
+	if err := w.flushRedacted(); err != nil {
+		return err
+	}
+
+	return w.BuildResponseOutputWriter.SendTsuruConfig(tc)
+}
@@ -6,19 +6,25 @@ package build_test
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	. "github.com/tsuru/deploy-agent/pkg/build"
 	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+	"github.com/tsuru/deploy-agent/pkg/util"
 )
 
 func TestIsTsuruYaml(t *testing.T) {
@@ -209,11 +215,63 @@ func TestProcfileCandidates_Pick(t *testing.T) {
 	}
 }
 
+func TestValidateTsuruConfigSearch(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		search        TsuruConfigSearch
+		expectedError string
+	}{
+		{},
+		{
+			search: TsuruConfigSearch{Dirs: []string{"/srv/app"}, TsuruYamlNames: []string{"deploy.yaml"}, ProcfileNames: []string{"Procfile.tsuru"}},
+		},
+		{
+			search:        TsuruConfigSearch{Dirs: []string{"srv/app"}},
+			expectedError: `tsuru config search dir "srv/app" must be an absolute path`,
+		},
+		{
+			search:        TsuruConfigSearch{TsuruYamlNames: []string{"config/tsuru.yaml"}},
+			expectedError: `tsuru config file name "config/tsuru.yaml" must be a base name`,
+		},
+		{
+			search:        TsuruConfigSearch{ProcfileNames: []string{""}},
+			expectedError: `tsuru config file name "" must be a base name`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run("", func(t *testing.T) {
+			err := ValidateTsuruConfigSearch(tt.search)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTsuruConfigSearch_Merge(t *testing.T) {
+	t.Parallel()
+
+	got := TsuruConfigSearch{Dirs: []string{"/srv/app"}, ProcfileNames: []string{"Procfile.tsuru"}}.
+		Merge(TsuruConfigSearch{Dirs: []string{"/opt/service", "/srv/app"}, TsuruYamlNames: []string{"deploy.yaml"}})
+
+	assert.Equal(t, TsuruConfigSearch{
+		Dirs:           []string{"/srv/app", "/opt/service"},
+		TsuruYamlNames: []string{"deploy.yaml"},
+		ProcfileNames:  []string{"Procfile.tsuru"},
+	}, got)
+}
+
 func TestExtractTsuruAppFilesFromAppSourceContext(t *testing.T) {
 	t.Parallel()
 
 	cases := []struct {
 		file          func(t *testing.T) io.Reader
+		search        TsuruConfigSearch
+		ignoreFile    string
 		expected      *pb.TsuruConfig
 		expectedError string
 	}{
@@ -221,7 +279,7 @@ func TestExtractTsuruAppFilesFromAppSourceContext(t *testing.T) {
 			file: func(t *testing.T) io.Reader {
 				return strings.NewReader(`not gzip`)
 			},
-			expectedError: "app source data must be a GZIP compressed file: unexpected EOF",
+			expectedError: "invalid archive: archive must be either tar, tar.gz, tar.zst or zip",
 		},
 
 		{
@@ -232,7 +290,28 @@ func TestExtractTsuruAppFilesFromAppSourceContext(t *testing.T) {
 				z.Close()
 				return &b
 			},
-			expectedError: "failed to read next file in the tarball: unexpected EOF",
+			expectedError: "invalid archive: failed to read next file: unexpected EOF",
+		},
+
+		{
+			file: func(t *testing.T) io.Reader {
+				var buffer bytes.Buffer
+				zw := zip.NewWriter(&buffer)
+				for name, content := range map[string]string{"Procfile": "web: ./server.sh", "tsuru.yaml": "# Tsuru YAML"} {
+					f, err := zw.Create(name)
+					require.NoError(t, err)
+					_, err = io.WriteString(f, content)
+					require.NoError(t, err)
+				}
+				require.NoError(t, zw.Close())
+				return &buffer
+			},
+			expected: &pb.TsuruConfig{
+				TsuruYaml:     "# Tsuru YAML",
+				TsuruYamlPath: "/home/application/current/tsuru.yaml",
+				Procfile:      "web: ./server.sh",
+				ProcfilePath:  "/home/application/current/Procfile",
+			},
 		},
 
 		{
@@ -248,8 +327,57 @@ func TestExtractTsuruAppFilesFromAppSourceContext(t *testing.T) {
 				return &buffer
 			},
 			expected: &pb.TsuruConfig{
-				TsuruYaml: "# Tsuru YAML",
-				Procfile:  `web: /path/to/server.sh --address 0.0.0.0:${PORT}`,
+				TsuruYaml:     "# Tsuru YAML",
+				TsuruYamlPath: "/home/application/current/tsuru.yaml",
+				Procfile:      `web: /path/to/server.sh --address 0.0.0.0:${PORT}`,
+				ProcfilePath:  "/home/application/current/Procfile",
+			},
+		},
+
+		{
+			file: func(t *testing.T) io.Reader {
+				var buffer bytes.Buffer
+				newTsuruAppSource(t, &buffer, map[string]string{
+					"Procfile.tsuru": "web: ./server.sh",
+					"deploy.yaml":    "# Tsuru YAML",
+				})
+				return &buffer
+			},
+			search: TsuruConfigSearch{TsuruYamlNames: []string{"deploy.yaml"}, ProcfileNames: []string{"Procfile.tsuru"}},
+			expected: &pb.TsuruConfig{
+				TsuruYaml:     "# Tsuru YAML",
+				TsuruYamlPath: "/home/application/current/deploy.yaml",
+				Procfile:      "web: ./server.sh",
+				ProcfilePath:  "/home/application/current/Procfile.tsuru",
+			},
+		},
+
+		{
+			file: func(t *testing.T) io.Reader {
+				var buffer bytes.Buffer
+				z, err := gzip.NewWriterLevel(&buffer, gzip.NoCompression)
+				require.NoError(t, err)
+				makeTarballFile(t, z, map[string]string{"tsuru.yaml": strings.Repeat("#", MaxTsuruConfigSize+1)})
+				require.NoError(t, z.Close())
+				return &buffer
+			},
+			expectedError: "rpc error: code = InvalidArgument desc = /home/application/current/tsuru.yaml exceeds the limit of 1048576 bytes",
+		},
+
+		{
+			file: func(t *testing.T) io.Reader {
+				var buffer bytes.Buffer
+				newTsuruAppSource(t, &buffer, map[string]string{
+					".tsuruignore": "tsuru.yaml\n",
+					"Procfile":     "web: ./server.sh",
+					"tsuru.yaml":   "# Ignored Tsuru YAML",
+				})
+				return &buffer
+			},
+			ignoreFile: ".tsuruignore",
+			expected: &pb.TsuruConfig{
+				Procfile:     "web: ./server.sh",
+				ProcfilePath: "/home/application/current/Procfile",
 			},
 		},
 	}
@@ -257,7 +385,19 @@ func TestExtractTsuruAppFilesFromAppSourceContext(t *testing.T) {
 	for _, tt := range cases {
 		t.Run("", func(t *testing.T) {
 			require.NotNil(t, tt.file)
-			tsuruFiles, err := ExtractTsuruAppFilesFromAppSourceContext(context.TODO(), tt.file(t))
+			data, err := io.ReadAll(tt.file(t))
+			require.NoError(t, err)
+
+			var tsuruFiles *pb.TsuruConfig
+			archive, err := util.NewArchive(data)
+			if err == nil && tt.ignoreFile != "" {
+				archive, _, err = util.ExcludeIgnoredFiles(context.TODO(), archive, tt.ignoreFile)
+			}
+
+			if err == nil {
+				tsuruFiles, err = ExtractTsuruAppFilesFromAppSourceContext(context.TODO(), archive, DefaultTsuruPlatformWorkingDir, tt.search)
+			}
+
 			if err != nil {
 				require.EqualError(t, err, tt.expectedError)
 				return
@@ -268,55 +408,81 @@ func TestExtractTsuruAppFilesFromAppSourceContext(t *testing.T) {
 	}
 }
 
-func TestExtractTsuruAppFilesFromContainerImageTarball(t *testing.T) {
+func TestExtractTsuruAppFiles(t *testing.T) {
 	t.Parallel()
 
+	files := map[string]string{
+		"/srv/app/Procfile":                    "web: ./server",
+		"/srv/app/tsuru.yaml":                  "# Tsuru YAML from /srv/app",
+		"/home/application/current/tsuru.yaml": "# Tsuru YAML from default dir",
+		"/Procfile":                            "web: ./bad-server",
+		"/large/tsuru.yaml":                    strings.Repeat("#", MaxTsuruConfigSize+1),
+	}
+
+	readFile := func(ctx context.Context, filename string) ([]byte, error) {
+		if filename == "/broken/Procfile" {
+			return nil, errors.New("some error")
+		}
+
+		data, found := files[filename]
+		if !found {
+			return nil, fs.ErrNotExist
+		}
+
+		return []byte(data), nil
+	}
+
 	cases := []struct {
-		file          func(t *testing.T) io.Reader
+		workingDir    string
+		search        TsuruConfigSearch
 		expected      *pb.TsuruConfig
 		expectedError string
 	}{
 		{
-			file: func(t *testing.T) io.Reader {
-				return strings.NewReader(`not tarball`)
+			expected: &pb.TsuruConfig{
+				Procfile:      "web: ./bad-server",
+				ProcfilePath:  "/Procfile",
+				TsuruYaml:     "# Tsuru YAML from default dir",
+				TsuruYamlPath: "/home/application/current/tsuru.yaml",
 			},
-			expectedError: "failed to read next file in the tarball: unexpected EOF",
 		},
-
 		{
-			file: func(t *testing.T) io.Reader {
-				var buffer bytes.Buffer
-				makeTarballFile(t, &buffer, map[string]string{
-					"/home/application/current/Procfile":      "Awesome Procfile",
-					"/home/application/current/demo/Procfile": "bad Procfile",
-					"/app/user/Procfile":                      "bad Procfile",
-					"/tmp/Procfile":                           "bad Procfile",
-					"/Procfile":                               "bad Procfile",
-
-					"/home/application/current/tsuru.yml":      "Awesome Tsuru YAML",
-					"/home/application/current/demo/tsuru.yml": "bad Tsuru YAML",
-					"/app/user/tsuru.yml":                      "bad Tsuru YAML",
-					"/tsuru.yml":                               "bad Tsuru YAML",
-				})
-				return &buffer
+			workingDir: "/srv/app",
+			expected: &pb.TsuruConfig{
+				Procfile:      "web: ./server",
+				ProcfilePath:  "/srv/app/Procfile",
+				TsuruYaml:     "# Tsuru YAML from /srv/app",
+				TsuruYamlPath: "/srv/app/tsuru.yaml",
 			},
+		},
+		{
+			search: TsuruConfigSearch{Dirs: []string{"/srv/app"}},
 			expected: &pb.TsuruConfig{
-				TsuruYaml: "Awesome Tsuru YAML",
-				Procfile:  `Awesome Procfile`,
+				Procfile:      "web: ./server",
+				ProcfilePath:  "/srv/app/Procfile",
+				TsuruYaml:     "# Tsuru YAML from /srv/app",
+				TsuruYamlPath: "/srv/app/tsuru.yaml",
 			},
 		},
+		{
+			workingDir:    "/broken",
+			expectedError: "failed to read /broken/Procfile: some error",
+		},
+		{
+			workingDir:    "/large",
+			expectedError: "rpc error: code = InvalidArgument desc = /large/tsuru.yaml exceeds the limit of 1048576 bytes",
+		},
 	}
 
 	for _, tt := range cases {
 		t.Run("", func(t *testing.T) {
-			require.NotNil(t, tt.file)
-			tsuruFiles, err := ExtractTsuruAppFilesFromContainerImageTarball(context.TODO(), tt.file(t), "")
-			if err != nil {
+			tc, err := ExtractTsuruAppFiles(context.TODO(), tt.workingDir, tt.search, readFile)
+			if tt.expectedError != "" {
 				require.EqualError(t, err, tt.expectedError)
 				return
 			}
 			require.NoError(t, err)
-			assert.Equal(t, tsuruFiles, tt.expected)
+			assert.Equal(t, tt.expected, tc)
 		})
 	}
 }
@@ -349,6 +515,53 @@ func makeTarballFile(t *testing.T, w io.Writer, files map[string]string) {
 	}
 }
 
+func TestParseExposedPorts(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		exposedPorts  []string
+		expected      []*pb.ContainerImagePort
+		expectedError string
+	}{
+		{},
+		{
+			exposedPorts: []string{"8080/tcp", "53/udp", "53/tcp", "9000", "80/TCP", "3868/sctp"},
+			expected: []*pb.ContainerImagePort{
+				{Port: 53, Protocol: "tcp"},
+				{Port: 53, Protocol: "udp"},
+				{Port: 80, Protocol: "tcp"},
+				{Port: 3868, Protocol: "sctp"},
+				{Port: 8080, Protocol: "tcp"},
+				{Port: 9000, Protocol: "tcp"},
+			},
+		},
+		{
+			exposedPorts:  []string{"http/tcp"},
+			expectedError: `invalid exposed port "http/tcp"`,
+		},
+		{
+			exposedPorts:  []string{"70000/tcp"},
+			expectedError: `invalid exposed port "70000/tcp"`,
+		},
+		{
+			exposedPorts:  []string{"8080/icmp"},
+			expectedError: `invalid protocol of exposed port "8080/icmp"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run("", func(t *testing.T) {
+			ports, err := ParseExposedPorts(tt.exposedPorts)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ports)
+		})
+	}
+}
+
 func TestBuildContainerfile(t *testing.T) {
 	cases := []struct {
 		expected string
@@ -398,6 +611,149 @@ RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid
     && { sh -lc 'mkdir -p /tmp/foo'; } \
     && { sh -lc 'echo "Hello world" > /tmp/foo/bar'; } \
     && :
+`,
+		},
+		{
+			params: BuildContainerfileParams{
+				Image:         "tsuru/python:latest",
+				AppName:       "my-app",
+				CacheIDSecret: []byte("secret"),
+				CacheDirs:     []string{"/home/application/.cache/pip", "/home/application/.npm"},
+			},
+			expected: `
+FROM tsuru/python:latest
+
+WORKDIR /home/application/current
+
+COPY ./application.tar.gz /home/application/archive.tar.gz
+
+ARG tsuru_deploy_cache=1
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    --mount=type=cache,id=tsuru-cache-ffa95434cf81bfe1d8600c979e4436d8045d811b6fe0c7ac6eb5b1f69dbd8e9f,target=/home/application/.cache/pip,uid=1000,gid=1000 \
+    --mount=type=cache,id=tsuru-cache-a960283fe142c313d33cb0b514b90efaab201a0923f8b19e39a23d4d2550ef2a,target=/home/application/.npm,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz \
+    && :
+`,
+		},
+		{
+			params: BuildContainerfileParams{
+				Image:         "registry.example.com/platforms/ruby:latest",
+				AppName:       "my-app",
+				CacheIDSecret: []byte("secret"),
+				CacheDirs:     []string{"/srv/bundle"},
+				Platform: PlatformMetadata{
+					WorkingDir:    "/srv/app",
+					DeployCommand: "/usr/local/bin/deploy",
+					ArchivePath:   "/tmp/app.tar.gz",
+					UID:           33,
+					GID:           33,
+				},
+			},
+			expected: `
+FROM registry.example.com/platforms/ruby:latest
+
+WORKDIR /srv/app
+
+COPY ./application.tar.gz /tmp/app.tar.gz
+
+ARG tsuru_deploy_cache=1
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=33,gid=33 \
+    --mount=type=cache,id=tsuru-cache-89c9c9a61171f851c24f3d842b8e219aed036349490cea319d12bac18a067eb6,target=/srv/bundle,uid=33,gid=33 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && /usr/local/bin/deploy archive file:///tmp/app.tar.gz \
+    && :
+`,
+		},
+		{
+			params: BuildContainerfileParams{
+				Image:     "tsuru/python:latest",
+				CacheDirs: []string{"/home/application/.cache/pip"},
+			},
+			expected: `
+FROM tsuru/python:latest
+
+WORKDIR /home/application/current
+
+COPY ./application.tar.gz /home/application/archive.tar.gz
+
+ARG tsuru_deploy_cache=1
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz \
+    && :
+`,
+		},
+		{
+			params: BuildContainerfileParams{
+				Image:                  "tsuru/python:latest",
+				AppName:                "my-app",
+				CacheIDSecret:          []byte("secret"),
+				CacheDirs:              []string{"/home/application/.cache/pip"},
+				BuildHooks:             []string{"python manage.py collectstatic", `echo "it's done"`},
+				SeparateBuildHookSteps: true,
+				BuildHookTimeout:       90 * time.Second,
+			},
+			expected: `
+FROM tsuru/python:latest
+
+WORKDIR /home/application/current
+
+COPY ./application.tar.gz /home/application/archive.tar.gz
+
+ARG tsuru_deploy_cache=1
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    --mount=type=cache,id=tsuru-cache-ffa95434cf81bfe1d8600c979e4436d8045d811b6fe0c7ac6eb5b1f69dbd8e9f,target=/home/application/.cache/pip,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz \
+    && :
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    --mount=type=cache,id=tsuru-cache-ffa95434cf81bfe1d8600c979e4436d8045d811b6fe0c7ac6eb5b1f69dbd8e9f,target=/home/application/.cache/pip,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && tsuru_build_hook=0 timeout 90 sh -lc 'python manage.py collectstatic'
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    --mount=type=cache,id=tsuru-cache-ffa95434cf81bfe1d8600c979e4436d8045d811b6fe0c7ac6eb5b1f69dbd8e9f,target=/home/application/.cache/pip,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && tsuru_build_hook=1 timeout 90 sh -lc 'echo "it'"'"'s done"'
+`,
+		},
+		{
+			params: BuildContainerfileParams{
+				Image:                  "tsuru/scratch:latest",
+				BuildHooks:             []string{"make assets"},
+				SeparateBuildHookSteps: true,
+			},
+			expected: `
+FROM tsuru/scratch:latest
+
+WORKDIR /home/application/current
+
+COPY ./application.tar.gz /home/application/archive.tar.gz
+
+ARG tsuru_deploy_cache=1
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && /var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz \
+    && :
+
+RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid=1000,gid=1000 \
+    [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh \
+    && [ -f ~/.profile ] && . ~/.profile \
+    && tsuru_build_hook=0 sh -lc 'make assets'
 `,
 		},
 	}
@@ -410,3 +766,104 @@ RUN --mount=type=secret,id=tsuru-app-envvars,target=/var/run/secrets/envs.sh,uid
 		})
 	}
 }
+
+func TestBuildResponseOutputWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("size-based flush", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &fakeBuildServer{}
+		w := NewBuildResponseOutputWriter(stream, nil)
+		w.FlushInterval = time.Hour
+
+		line := strings.Repeat("x", 1023) + "\n"
+		for i := 0; i < 64; i++ {
+			_, err := io.WriteString(w, line)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, []string{strings.Repeat(line, 32), strings.Repeat(line, 32)}, stream.outputs())
+	})
+
+	t.Run("time-based flush", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &fakeBuildServer{}
+		w := NewBuildResponseOutputWriter(stream, nil)
+		w.FlushInterval = 10 * time.Millisecond
+
+		for _, s := range []string{"#1 ", "[internal] load build definition", "\n"} {
+			_, err := io.WriteString(w, s)
+			require.NoError(t, err)
+		}
+
+		assert.Eventually(t, func() bool {
+			return assert.ObjectsAreEqual([]string{"#1 [internal] load build definition\n"}, stream.outputs())
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("flush before tsuru config", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &fakeBuildServer{}
+		w := NewBuildResponseOutputWriter(stream, nil)
+
+		fmt.Fprintln(w, "--> Done")
+		require.NoError(t, w.SendTsuruConfig(&pb.TsuruConfig{Procfile: "web: ./app"}))
+
+		require.Len(t, stream.responses, 2)
+		assert.Equal(t, "--> Done\n", stream.responses[0].GetOutput())
+		assert.Equal(t, "web: ./app", stream.responses[1].GetTsuruConfig().GetProcfile())
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &fakeBuildServer{err: errors.New("transport is closing")}
+
+		var got error
+		w := NewBuildResponseOutputWriter(stream, func(err error) { got = err })
+
+		_, err := io.WriteString(w, "#1 DONE 0.1s\n")
+		require.NoError(t, err)
+
+		assert.EqualError(t, w.Flush(), "transport is closing")
+		assert.EqualError(t, got, "transport is closing")
+
+		_, err = io.WriteString(w, "#2 DONE 0.2s\n")
+		assert.EqualError(t, err, "transport is closing")
+		assert.EqualError(t, w.SendTsuruConfig(&pb.TsuruConfig{}), "transport is closing")
+	})
+}
+
+type fakeBuildServer struct {
+	pb.Build_BuildServer
+	err       error
+	responses []*pb.BuildResponse
+	mu        sync.Mutex
+}
+
+func (s *fakeBuildServer) Send(r *pb.BuildResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.responses = append(s.responses, r)
+	return nil
+}
+
+func (s *fakeBuildServer) outputs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var outputs []string
+	for _, r := range s.responses {
+		outputs = append(outputs, r.GetOutput())
+	}
+
+	return outputs
+}
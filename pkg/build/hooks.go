@@ -0,0 +1,127 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// buildHookMarker is the shell variable which tags the build hook steps with
+// their index, so it shows up in the errors of failed steps.
+const buildHookMarker = "tsuru_build_hook"
+
+var (
+	buildHookMarkerRegexp = regexp.MustCompile(buildHookMarker + `=(\d+) `)
+	exitCodeRegexp        = regexp.MustCompile(`exit code: (\d+)`)
+)
+
+// BuildHookError is returned when a build hook, run as its own step, fails.
+// It's reported as codes.FailedPrecondition to gRPC clients, along with an
+// errdetails.ErrorInfo holding its fields as metadata.
+type BuildHookError struct {
+	// Index is the hook position in tsuru.yaml's hooks.build.
+	Index   int
+	Command string
+	// ExitCode is the hook's exit code, or -1 when unknown.
+	ExitCode int
+	// Timeout is set when the hook was killed for exceeding it.
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *BuildHookError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("build hook hooks.build[%d] %q timed out after %s", e.Index, e.Command, e.Timeout)
+	}
+
+	if e.ExitCode >= 0 {
+		return fmt.Sprintf("build hook hooks.build[%d] %q failed with exit code %d", e.Index, e.Command, e.ExitCode)
+	}
+
+	return fmt.Sprintf("build hook hooks.build[%d] %q failed: %s", e.Index, e.Command, e.Err)
+}
+
+func (e *BuildHookError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BuildHookError) GRPCStatus() *status.Status {
+	st := status.New(codes.FailedPrecondition, e.Error())
+
+	metadata := map[string]string{
+		"index":     strconv.Itoa(e.Index),
+		"command":   e.Command,
+		"exit_code": strconv.Itoa(e.ExitCode),
+	}
+
+	if e.Timeout > 0 {
+		metadata["timeout"] = e.Timeout.String()
+	}
+
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   "BUILD_HOOK_FAILED",
+		Domain:   "deploy-agent.tsuru.io",
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st
+	}
+
+	return detailed
+}
+
+// NewBuildHookError returns a BuildHookError when err comes from a build hook
+// step rendered by BuildHookCommand, otherwise it returns err as is.
+// stepDuration returns how long the step whose name contains the given text
+// ran (zero when unknown), so hooks running up to the timeout are reported
+// as timed out regardless of their exit code.
+func NewBuildHookError(err error, p BuildContainerfileParams, stepDuration func(substr string) time.Duration) error {
+	if err == nil || !p.SeparateBuildHookSteps {
+		return err
+	}
+
+	msg := err.Error()
+
+	index := -1
+	for _, m := range buildHookMarkerRegexp.FindAllStringSubmatch(msg, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if index >= 0 && index != n { // ambiguous, e.g. hooks chained in a custom template
+			return err
+		}
+
+		index = n
+	}
+
+	if index < 0 || index >= len(p.BuildHooks) {
+		return err
+	}
+
+	herr := &BuildHookError{Index: index, Command: p.BuildHooks[index], ExitCode: -1, Err: err}
+
+	if m := exitCodeRegexp.FindStringSubmatch(msg); m != nil {
+		herr.ExitCode, _ = strconv.Atoi(m[1])
+	}
+
+	// NOTE: exit codes after timeout vary (e.g. 124 on coreutils, 143 on
+	// busybox) and hooks may exit with them as well, so the step duration
+	// tells timed out hooks apart.
+	if p.BuildHookTimeout > 0 && stepDuration != nil && stepDuration(buildHookStepMarker(index)) >= p.BuildHookTimeout {
+		herr.Timeout = p.BuildHookTimeout
+	}
+
+	return herr
+}
+
+// buildHookStepMarker returns the text tagging the i-th build hook step.
+func buildHookStepMarker(i int) string {
+	return fmt.Sprintf("%s=%d ", buildHookMarker, i)
+}
@@ -0,0 +1,134 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+)
+
+func TestNewBuildHookError(t *testing.T) {
+	t.Parallel()
+
+	params := BuildContainerfileParams{
+		BuildHooks:             []string{"make deps", "make assets"},
+		SeparateBuildHookSteps: true,
+	}
+
+	failedStep := func(cmd string, exitCode string) error {
+		return errors.New(`process "/bin/sh -c [ -f /var/run/secrets/envs.sh ] && . /var/run/secrets/envs.sh && [ -f ~/.profile ] && . ~/.profile && ` + cmd + `" did not complete successfully: exit code: ` + exitCode)
+	}
+
+	cases := map[string]struct {
+		err          error
+		params       func(p BuildContainerfileParams) BuildContainerfileParams
+		stepDuration time.Duration
+		expected     error
+		expectedInfo map[string]string
+	}{
+		"nil error": {},
+
+		"not a build hook step": {
+			err:      failedStep("/var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz", "1"),
+			expected: failedStep("/var/lib/tsuru/deploy archive file:///home/application/archive.tar.gz", "1"),
+		},
+
+		"hooks chained into deploy's step": {
+			err:      failedStep("tsuru_build_hook=1 sh -lc 'make assets'", "2"),
+			params:   func(p BuildContainerfileParams) BuildContainerfileParams { p.SeparateBuildHookSteps = false; return p },
+			expected: failedStep("tsuru_build_hook=1 sh -lc 'make assets'", "2"),
+		},
+
+		"ambiguous hook": {
+			err:      failedStep("tsuru_build_hook=0 sh -lc 'make deps' && tsuru_build_hook=1 sh -lc 'make assets'", "2"),
+			expected: failedStep("tsuru_build_hook=0 sh -lc 'make deps' && tsuru_build_hook=1 sh -lc 'make assets'", "2"),
+		},
+
+		"hook out of range": {
+			err:      failedStep("tsuru_build_hook=2 sh -lc 'make test'", "2"),
+			expected: failedStep("tsuru_build_hook=2 sh -lc 'make test'", "2"),
+		},
+
+		"failed hook": {
+			err:          failedStep("tsuru_build_hook=1 sh -lc 'make assets'", "2"),
+			expected:     status.Error(codes.FailedPrecondition, `build hook hooks.build[1] "make assets" failed with exit code 2`),
+			expectedInfo: map[string]string{"index": "1", "command": "make assets", "exit_code": "2"},
+		},
+
+		"failed hook w/o exit code": {
+			err:      errors.New(`process "/bin/sh -c tsuru_build_hook=0 sh -lc 'make deps'" was killed`),
+			expected: status.Error(codes.FailedPrecondition, `build hook hooks.build[0] "make deps" failed: process "/bin/sh -c tsuru_build_hook=0 sh -lc 'make deps'" was killed`),
+		},
+
+		"timed out hook": {
+			err:          failedStep("tsuru_build_hook=0 timeout 60 sh -lc 'make deps'", "143"),
+			params:       func(p BuildContainerfileParams) BuildContainerfileParams { p.BuildHookTimeout = time.Minute; return p },
+			stepDuration: time.Minute + 100*time.Millisecond,
+			expected:     status.Error(codes.FailedPrecondition, `build hook hooks.build[0] "make deps" timed out after 1m0s`),
+			expectedInfo: map[string]string{"index": "0", "command": "make deps", "exit_code": "143", "timeout": "1m0s"},
+		},
+
+		"exit code 124 before timeout": {
+			err:          failedStep("tsuru_build_hook=0 timeout 60 sh -lc 'make deps'", "124"),
+			params:       func(p BuildContainerfileParams) BuildContainerfileParams { p.BuildHookTimeout = time.Minute; return p },
+			stepDuration: 5 * time.Second,
+			expected:     status.Error(codes.FailedPrecondition, `build hook hooks.build[0] "make deps" failed with exit code 124`),
+		},
+
+		"exit code 124 w/o timeout": {
+			err:          failedStep("tsuru_build_hook=0 sh -lc 'make deps'", "124"),
+			stepDuration: time.Hour,
+			expected:     status.Error(codes.FailedPrecondition, `build hook hooks.build[0] "make deps" failed with exit code 124`),
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := params
+			if tt.params != nil {
+				p = tt.params(p)
+			}
+
+			stepDuration := func(substr string) time.Duration {
+				assert.Regexp(t, `^tsuru_build_hook=\d `, substr)
+				return tt.stepDuration
+			}
+
+			err := NewBuildHookError(tt.err, p, stepDuration)
+			if tt.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.Equal(t, status.Convert(tt.expected).Message(), status.Convert(err).Message())
+			assert.Equal(t, status.Code(tt.expected), status.Code(err))
+
+			var herr *BuildHookError
+			if errors.As(err, &herr) {
+				assert.ErrorIs(t, err, tt.err)
+			}
+
+			if tt.expectedInfo != nil {
+				details := status.Convert(err).Details()
+				require.Len(t, details, 1)
+				require.IsType(t, &errdetails.ErrorInfo{}, details[0])
+				assert.Equal(t, "BUILD_HOOK_FAILED", details[0].(*errdetails.ErrorInfo).Reason)
+				assert.Equal(t, tt.expectedInfo, details[0].(*errdetails.ErrorInfo).Metadata)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+const (
+	// See more: https://github.com/opencontainers/image-spec/blob/main/annotations.md
+	OCIImageCreatedLabel    = "org.opencontainers.image.created"
+	OCIImageTitleLabel      = "org.opencontainers.image.title"
+	OCIImageBaseNameLabel   = "org.opencontainers.image.base.name"
+	OCIImageBaseDigestLabel = "org.opencontainers.image.base.digest"
+
+	TsuruAppNameLabel           = "io.tsuru.app.name"
+	TsuruPlatformNameLabel      = "io.tsuru.platform.name"
+	TsuruBuildKindLabel         = "io.tsuru.build.kind"
+	TsuruBuildTimeLabel         = "io.tsuru.build.time"
+	TsuruSourceImageLabel       = "io.tsuru.source-image.name"
+	TsuruSourceImageDigestLabel = "io.tsuru.source-image.digest"
+)
+
+// ImageMetadata holds the data which ties a built container image to the
+// Tsuru app (or platform) it came from. It's also the data available to
+// operator-defined label templates.
+type ImageMetadata struct {
+	BuildTime         time.Time
+	AppName           string
+	PlatformName      string
+	BuildKind         string
+	SourceImage       string
+	SourceImageDigest string
+	DestinationImages []string
+}
+
+// NewImageMetadata returns the metadata of the image built from r. The source
+// date epoch, when set, takes the place of buildTime, so reproducible builds
+// get the same labels (and annotations) every time.
+func NewImageMetadata(r *pb.BuildRequest, sourceImageDigest string, buildTime time.Time) ImageMetadata {
+	if epoch := r.SourceDateEpoch; epoch != nil {
+		buildTime = epoch.AsTime()
+	}
+
+	m := ImageMetadata{
+		BuildTime:         buildTime.UTC(),
+		BuildKind:         BuildKindName(r.Kind),
+		SourceImage:       r.SourceImage,
+		SourceImageDigest: sourceImageDigest,
+		DestinationImages: r.DestinationImages,
+	}
+
+	if r.App != nil {
+		m.AppName = r.App.Name
+		m.PlatformName = r.App.Platform // app builds don't set r.Platform
+	}
+
+	if r.Platform != nil {
+		m.PlatformName = r.Platform.Name
+	}
+
+	return m
+}
+
+// BuildKindName returns the build kind in a short form, e.g. "app_build_with_source_upload".
+func BuildKindName(k pb.BuildKind) string {
+	name, found := pb.BuildKind_name[int32(k)]
+	if !found {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimPrefix(name, "BUILD_KIND_"))
+}
+
+type ImageLabelTemplates map[string]*template.Template
+
+// ParseImageLabelTemplates parses label values as text/template, which are
+// rendered against ImageMetadata, e.g. "com.example.team={{ .AppName }}-team".
+func ParseImageLabelTemplates(labels map[string]string) (ImageLabelTemplates, error) {
+	templates := make(ImageLabelTemplates, len(labels))
+
+	for k, v := range labels {
+		if k == "" {
+			return nil, errors.New("image label name cannot be empty")
+		}
+
+		t, err := template.New(k).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template of image label %q: %w", k, err)
+		}
+
+		templates[k] = t
+	}
+
+	return templates, nil
+}
+
+// ImageLabels returns the labels (and manifest annotations) to be stamped on
+// the container image. Labels with empty values are omitted. Operator-defined
+// labels cannot override the standard ones.
+func ImageLabels(m ImageMetadata, templates ImageLabelTemplates) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	for k, t := range templates {
+		var b bytes.Buffer
+		if err := t.Execute(&b, m); err != nil {
+			return nil, fmt.Errorf("failed to render image label %q: %w", k, err)
+		}
+
+		labels[k] = b.String()
+	}
+
+	var created string
+	if !m.BuildTime.IsZero() {
+		created = m.BuildTime.Format(time.RFC3339)
+	}
+
+	title := m.AppName
+	if title == "" {
+		title = m.PlatformName
+	}
+
+	for k, v := range map[string]string{
+		OCIImageCreatedLabel:        created,
+		OCIImageTitleLabel:          title,
+		OCIImageBaseNameLabel:       m.SourceImage,
+		OCIImageBaseDigestLabel:     m.SourceImageDigest,
+		TsuruAppNameLabel:           m.AppName,
+		TsuruPlatformNameLabel:      m.PlatformName,
+		TsuruBuildKindLabel:         m.BuildKind,
+		TsuruBuildTimeLabel:         created,
+		TsuruSourceImageLabel:       m.SourceImage,
+		TsuruSourceImageDigestLabel: m.SourceImageDigest,
+	} {
+		labels[k] = v
+	}
+
+	for k, v := range labels {
+		if v == "" {
+			delete(labels, k)
+		}
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestImageLabels(t *testing.T) {
+	t.Parallel()
+
+	buildTime := time.Date(2023, time.March, 14, 15, 9, 26, 0, time.FixedZone("BRT", -3*60*60))
+
+	cases := map[string]struct {
+		req               *pb.BuildRequest
+		sourceImageDigest string
+		labels            map[string]string
+		expected          map[string]string
+		expectedError     string
+	}{
+		"app deploy from source upload": {
+			req: &pb.BuildRequest{
+				Kind:        pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_SOURCE_UPLOAD,
+				App:         &pb.TsuruApp{Name: "my-app", Platform: "python"},
+				SourceImage: "tsuru/python:latest",
+			},
+			sourceImageDigest: "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			expected: map[string]string{
+				"org.opencontainers.image.created":     "2023-03-14T18:09:26Z",
+				"org.opencontainers.image.title":       "my-app",
+				"org.opencontainers.image.base.name":   "tsuru/python:latest",
+				"org.opencontainers.image.base.digest": "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+				"io.tsuru.app.name":                    "my-app",
+				"io.tsuru.platform.name":               "python",
+				"io.tsuru.build.kind":                  "app_build_with_source_upload",
+				"io.tsuru.build.time":                  "2023-03-14T18:09:26Z",
+				"io.tsuru.source-image.name":           "tsuru/python:latest",
+				"io.tsuru.source-image.digest":         "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			},
+		},
+
+		"platform build w/ custom labels": {
+			req: &pb.BuildRequest{
+				Kind:     pb.BuildKind_BUILD_KIND_PLATFORM_WITH_CONTAINER_FILE,
+				Platform: &pb.TsuruPlatform{Name: "python"},
+			},
+			labels: map[string]string{
+				"com.example.owner":  "team-{{ .PlatformName }}",
+				"com.example.static": "static value",
+				"com.example.empty":  "{{ .AppName }}",
+				"io.tsuru.app.name":  "cannot override",
+			},
+			expected: map[string]string{
+				"org.opencontainers.image.created": "2023-03-14T18:09:26Z",
+				"org.opencontainers.image.title":   "python",
+				"io.tsuru.platform.name":           "python",
+				"io.tsuru.build.kind":              "platform_with_container_file",
+				"io.tsuru.build.time":              "2023-03-14T18:09:26Z",
+				"com.example.owner":                "team-python",
+				"com.example.static":               "static value",
+			},
+		},
+
+		"build w/ source date epoch": {
+			req: &pb.BuildRequest{
+				Kind:            pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_FILE,
+				App:             &pb.TsuruApp{Name: "my-app"},
+				SourceDateEpoch: &timestamppb.Timestamp{Seconds: 1700000000},
+			},
+			expected: map[string]string{
+				"org.opencontainers.image.created": "2023-11-14T22:13:20Z",
+				"org.opencontainers.image.title":   "my-app",
+				"io.tsuru.app.name":                "my-app",
+				"io.tsuru.build.kind":              "app_build_with_container_file",
+				"io.tsuru.build.time":              "2023-11-14T22:13:20Z",
+			},
+		},
+
+		"template referencing unknown field": {
+			req: &pb.BuildRequest{
+				Kind: pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_IMAGE,
+				App:  &pb.TsuruApp{Name: "my-app"},
+			},
+			labels: map[string]string{
+				"com.example.foo": "{{ .NotFound }}",
+			},
+			expectedError: `failed to render image label "com.example.foo": template: com.example.foo:1:3: executing "com.example.foo" at <.NotFound>: can't evaluate field NotFound in type build.ImageMetadata`,
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			templates, err := ParseImageLabelTemplates(tt.labels)
+			require.NoError(t, err)
+
+			labels, err := ImageLabels(NewImageMetadata(tt.req, tt.sourceImageDigest, buildTime), templates)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, labels)
+		})
+	}
+}
+
+func TestParseImageLabelTemplates(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseImageLabelTemplates(map[string]string{"com.example.foo": "{{ .AppName "})
+	assert.EqualError(t, err, `failed to parse template of image label "com.example.foo": template: com.example.foo:1: unclosed action`)
+
+	_, err = ParseImageLabelTemplates(map[string]string{"": "bar"})
+	assert.EqualError(t, err, "image label name cannot be empty")
+}
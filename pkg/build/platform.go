@@ -0,0 +1,106 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Labels of platform's container image which describe how the app source data
+// is deployed on it. Missing labels fall back to the defaults below.
+const (
+	PlatformWorkingDirLabel    = "io.tsuru.platform.workdir"
+	PlatformDeployCommandLabel = "io.tsuru.platform.deploy-command"
+	PlatformUIDLabel           = "io.tsuru.platform.uid"
+	PlatformGIDLabel           = "io.tsuru.platform.gid"
+	PlatformArchivePathLabel   = "io.tsuru.platform.archive-path"
+)
+
+const (
+	DefaultTsuruPlatformDeployCommand = "/var/lib/tsuru/deploy"
+	DefaultTsuruPlatformArchivePath   = "/home/application/archive.tar.gz"
+	DefaultTsuruPlatformUID           = 1000
+	DefaultTsuruPlatformGID           = 1000
+)
+
+type PlatformMetadata struct {
+	// WorkingDir is where the app source data is deployed into.
+	WorkingDir string
+	// DeployCommand is the shell command which deploys the archive, it's
+	// called with "archive file://<ArchivePath>" arguments.
+	DeployCommand string
+	// ArchivePath is where the application.tar.gz is copied to.
+	ArchivePath string
+	// UID and GID are the owner of secrets and cache dirs, i.e. the user
+	// which runs the deploy command.
+	UID int
+	GID int
+}
+
+func DefaultPlatformMetadata() PlatformMetadata {
+	return PlatformMetadata{
+		WorkingDir:    DefaultTsuruPlatformWorkingDir,
+		DeployCommand: DefaultTsuruPlatformDeployCommand,
+		ArchivePath:   DefaultTsuruPlatformArchivePath,
+		UID:           DefaultTsuruPlatformUID,
+		GID:           DefaultTsuruPlatformGID,
+	}
+}
+
+// ParsePlatformMetadata reads the platform's metadata from its container image
+// labels. Invalid labels don't stop the parsing, instead their defaults are
+// kept and they're returned as problems.
+func ParsePlatformMetadata(labels map[string]string) (PlatformMetadata, []string) {
+	m := DefaultPlatformMetadata()
+
+	var problems []string
+
+	absPath := func(label string, dst *string) {
+		value, found := labels[label]
+		if !found {
+			return
+		}
+
+		if !path.IsAbs(value) || strings.ContainsAny(value, " \t\n\\'\"") {
+			problems = append(problems, fmt.Sprintf("label %s: %q must be an absolute path without spaces nor quotes", label, value))
+			return
+		}
+
+		*dst = path.Clean(value)
+	}
+
+	id := func(label string, dst *int) {
+		value, found := labels[label]
+		if !found {
+			return
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			problems = append(problems, fmt.Sprintf("label %s: %q must be a non-negative integer", label, value))
+			return
+		}
+
+		*dst = n
+	}
+
+	absPath(PlatformWorkingDirLabel, &m.WorkingDir)
+	absPath(PlatformArchivePathLabel, &m.ArchivePath)
+	id(PlatformUIDLabel, &m.UID)
+	id(PlatformGIDLabel, &m.GID)
+
+	if value, found := labels[PlatformDeployCommandLabel]; found {
+		if strings.TrimSpace(value) == "" || strings.Contains(value, "\n") {
+			problems = append(problems, fmt.Sprintf("label %s: %q must be a single-line command", PlatformDeployCommandLabel, value))
+		} else {
+			m.DeployCommand = strings.TrimSpace(value)
+		}
+	}
+
+	return m, problems
+}
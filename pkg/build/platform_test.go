@@ -0,0 +1,80 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+)
+
+func TestParsePlatformMetadata(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		labels           map[string]string
+		expected         PlatformMetadata
+		expectedProblems []string
+	}{
+		"no labels": {
+			expected: PlatformMetadata{
+				WorkingDir:    "/home/application/current",
+				DeployCommand: "/var/lib/tsuru/deploy",
+				ArchivePath:   "/home/application/archive.tar.gz",
+				UID:           1000,
+				GID:           1000,
+			},
+		},
+
+		"all labels": {
+			labels: map[string]string{
+				"io.tsuru.platform.workdir":        "/srv/app/",
+				"io.tsuru.platform.deploy-command": "/usr/local/bin/deploy --verbose",
+				"io.tsuru.platform.archive-path":   "/tmp/app.tar.gz",
+				"io.tsuru.platform.uid":            "0",
+				"io.tsuru.platform.gid":            "33",
+				"org.opencontainers.image.title":   "my-platform",
+			},
+			expected: PlatformMetadata{
+				WorkingDir:    "/srv/app",
+				DeployCommand: "/usr/local/bin/deploy --verbose",
+				ArchivePath:   "/tmp/app.tar.gz",
+				UID:           0,
+				GID:           33,
+			},
+		},
+
+		"invalid labels keep the defaults": {
+			labels: map[string]string{
+				"io.tsuru.platform.workdir":        "srv/app",
+				"io.tsuru.platform.deploy-command": " ",
+				"io.tsuru.platform.archive-path":   "/tmp/my app.tar.gz",
+				"io.tsuru.platform.uid":            "ubuntu",
+				"io.tsuru.platform.gid":            "-1",
+			},
+			expected: DefaultPlatformMetadata(),
+			expectedProblems: []string{
+				`label io.tsuru.platform.workdir: "srv/app" must be an absolute path without spaces nor quotes`,
+				`label io.tsuru.platform.archive-path: "/tmp/my app.tar.gz" must be an absolute path without spaces nor quotes`,
+				`label io.tsuru.platform.uid: "ubuntu" must be a non-negative integer`,
+				`label io.tsuru.platform.gid: "-1" must be a non-negative integer`,
+				`label io.tsuru.platform.deploy-command: " " must be a single-line command`,
+			},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, problems := ParsePlatformMetadata(tt.labels)
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, tt.expectedProblems, problems)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alessio/shellescape"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+var procfileLineRegexp = regexp.MustCompile(`^([\w-]+):\s*(\S.*)$`)
+
+// ParseProcfile returns the processes defined in the Procfile, in the same
+// order. Blank lines and comments (starting with #) are ignored. Invalid lines
+// don't stop the parsing, instead they're returned as problems.
+func ParseProcfile(procfile string) ([]*pb.TsuruProcess, []string) {
+	var processes []*pb.TsuruProcess
+	var problems []string
+
+	seen := make(map[string]bool)
+
+	s := bufio.NewScanner(strings.NewReader(procfile))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		matches := procfileLineRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			problems = append(problems, fmt.Sprintf("line %d: invalid process definition %q, must be in the <name>: <command> format", n, line))
+			continue
+		}
+
+		name, command := matches[1], strings.TrimSpace(matches[2])
+		if seen[name] {
+			problems = append(problems, fmt.Sprintf("line %d: process %q is already defined", n, name))
+			continue
+		}
+
+		seen[name] = true
+		processes = append(processes, &pb.TsuruProcess{Name: name, Command: command})
+	}
+
+	return processes, problems
+}
+
+// DeriveProcfile returns a Procfile with a single web process which runs the
+// container image's entrypoint followed by its command, just like the
+// container runtime does. If both are empty, it returns an empty string.
+func DeriveProcfile(ic *pb.ContainerImageConfig) string {
+	if ic == nil {
+		return ""
+	}
+
+	args := append(append([]string{}, ic.Entrypoint...), ic.Cmd...)
+	if len(args) == 0 {
+		return ""
+	}
+
+	return "web: " + shellescape.QuoteCommand(args) + "\n"
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestParseProcfile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		procfile          string
+		expectedProcesses []*pb.TsuruProcess
+		expectedProblems  []string
+	}{
+		{},
+		{
+			procfile: `# Comments and blank lines are ignored
+
+web: ./server --port ${PORT}
+worker-1:python worker.py   
+  cron: /usr/bin/cron -f
+`,
+			expectedProcesses: []*pb.TsuruProcess{
+				{Name: "web", Command: "./server --port ${PORT}"},
+				{Name: "worker-1", Command: "python worker.py"},
+				{Name: "cron", Command: "/usr/bin/cron -f"},
+			},
+		},
+		{
+			procfile: "web: ./server\nworker ./worker\nweb: ./another-server\nweb.1: ./server\nempty:\n",
+			expectedProcesses: []*pb.TsuruProcess{
+				{Name: "web", Command: "./server"},
+			},
+			expectedProblems: []string{
+				`line 2: invalid process definition "worker ./worker", must be in the <name>: <command> format`,
+				`line 3: process "web" is already defined`,
+				`line 4: invalid process definition "web.1: ./server", must be in the <name>: <command> format`,
+				`line 5: invalid process definition "empty:", must be in the <name>: <command> format`,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run("", func(t *testing.T) {
+			processes, problems := ParseProcfile(tt.procfile)
+			assert.Equal(t, tt.expectedProcesses, processes)
+			assert.Equal(t, tt.expectedProblems, problems)
+		})
+	}
+}
+
+func TestDeriveProcfile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		imageConfig *pb.ContainerImageConfig
+		expected    string
+	}{
+		{},
+		{
+			imageConfig: &pb.ContainerImageConfig{WorkingDir: "/app"},
+		},
+		{
+			imageConfig: &pb.ContainerImageConfig{Cmd: []string{"sh"}},
+			expected:    "web: sh\n",
+		},
+		{
+			imageConfig: &pb.ContainerImageConfig{
+				Entrypoint: []string{"/docker-entrypoint.sh"},
+				Cmd:        []string{"nginx", "-g", "daemon off;"},
+			},
+			expected: "web: /docker-entrypoint.sh nginx -g 'daemon off;'\n",
+		},
+		{
+			imageConfig: &pb.ContainerImageConfig{
+				Entrypoint: []string{"/bin/sh", "-c", `echo "it's ${PORT}"`},
+			},
+			expected: `web: /bin/sh -c 'echo "it'"'"'s ${PORT}"'` + "\n",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.expected, DeriveProcfile(tt.imageConfig))
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/tsuru/deploy-agent/pkg/util"
+)
+
+// rawJSONWriter keeps the output of rawjson progress mode as JSON lines: any
+// line other than BuildKit's solve statuses (e.g. deploy-agent's messages) is
+// sent as a RawJSONMessage. It also redacts secrets from the logs of solve
+// statuses, since their data is base64 encoded and so out of reach of
+// util.RedactingWriter.
+type rawJSONWriter struct {
+	w       io.Writer
+	secrets []string
+	line    []byte
+	logs    map[logStream]*logRedactor
+	mu      sync.Mutex
+}
+
+// RawJSONMessage is a line of the build output which isn't a solve status in
+// rawjson progress mode.
+type RawJSONMessage struct {
+	Message string
+}
+
+type logStream struct {
+	vertex digest.Digest
+	stream int
+}
+
+type logRedactor struct {
+	data      bytes.Buffer
+	redactor  *util.RedactingWriter
+	timestamp time.Time
+}
+
+func newRawJSONWriter(w io.Writer, secrets []string) *rawJSONWriter {
+	return &rawJSONWriter{w: w, secrets: secrets, logs: make(map[logStream]*logRedactor)}
+}
+
+func (w *rawJSONWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.line = append(w.line, p...)
+
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			break
+		}
+
+		err := w.writeLine(w.line[:i+1])
+		w.line = w.line[:copy(w.line, w.line[i+1:])]
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the held output, including the log data which could be the
+// beginning of a secret.
+func (w *rawJSONWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.line) > 0 {
+		err := w.writeLine(w.line)
+		w.line = w.line[:0]
+		if err != nil {
+			return err
+		}
+	}
+
+	keys := make([]logStream, 0, len(w.logs))
+	for k := range w.logs {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].vertex != keys[j].vertex {
+			return keys[i].vertex < keys[j].vertex
+		}
+
+		return keys[i].stream < keys[j].stream
+	})
+
+	var st client.SolveStatus
+	for _, k := range keys {
+		if l := w.flushLog(k); l != nil {
+			st.Logs = append(st.Logs, l)
+		}
+	}
+
+	if len(st.Logs) == 0 {
+		return nil
+	}
+
+	return json.NewEncoder(w.w).Encode(&st)
+}
+
+func (w *rawJSONWriter) writeLine(line []byte) error {
+	var st client.SolveStatus
+	if line[0] != '{' || json.Unmarshal(line, &st) != nil {
+		return w.writeMessage(line)
+	}
+
+	logs := st.Logs[:0]
+
+	for _, l := range st.Logs {
+		k := logStream{vertex: l.Vertex, stream: l.Stream}
+
+		r, found := w.logs[k]
+		if !found {
+			r = &logRedactor{}
+			r.redactor = util.NewRedactingWriter(&r.data, w.secrets)
+			w.logs[k] = r
+		}
+
+		r.redactor.Write(l.Data)
+		r.timestamp = l.Timestamp
+
+		if r.data.Len() == 0 { // held, may be the beginning of a secret
+			continue
+		}
+
+		l.Data = append([]byte(nil), r.data.Bytes()...)
+		r.data.Reset()
+		logs = append(logs, l)
+	}
+
+	for _, v := range st.Vertexes {
+		if v.Completed == nil {
+			continue
+		}
+
+		for k := range w.logs {
+			if k.vertex != v.Digest {
+				continue
+			}
+
+			if l := w.flushLog(k); l != nil {
+				logs = append(logs, l)
+			}
+		}
+	}
+
+	st.Logs = logs
+
+	if len(st.Vertexes) == 0 && len(st.Statuses) == 0 && len(st.Logs) == 0 && len(st.Warnings) == 0 {
+		return nil
+	}
+
+	return json.NewEncoder(w.w).Encode(&st)
+}
+
+func (w *rawJSONWriter) writeMessage(line []byte) error {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) == 0 {
+		return nil
+	}
+
+	// NOTE: secrets are redacted before encoding, since escaping could
+	// change them (e.g. quotes).
+	var message bytes.Buffer
+	redactor := util.NewRedactingWriter(&message, w.secrets)
+	redactor.Write(line)
+	redactor.Flush()
+
+	return json.NewEncoder(w.w).Encode(&RawJSONMessage{Message: message.String()})
+}
+
+// flushLog returns the held data of the log stream k, if any, and forgets it.
+func (w *rawJSONWriter) flushLog(k logStream) *client.VertexLog {
+	r := w.logs[k]
+	delete(w.logs, k)
+
+	r.redactor.Flush()
+	if r.data.Len() == 0 {
+		return nil
+	}
+
+	return &client.VertexLog{Vertex: k.vertex, Stream: k.stream, Data: r.data.Bytes(), Timestamp: r.timestamp}
+}
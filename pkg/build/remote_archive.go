@@ -0,0 +1,181 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+const (
+	DefaultRemoteArchiveMaxSize = 1 << 30 // 1 GiB
+	DefaultRemoteArchiveTimeout = 15 * time.Minute
+
+	maxRemoteArchiveRedirects = 10
+)
+
+type RemoteArchiveOptions struct {
+	// Client is the HTTP client used to download the archive, its redirect
+	// policy is extended to reject redirects from https to http.
+	// Defaults to a client using http.DefaultTransport.
+	Client *http.Client
+	// MaxSize is the max size in bytes of the archive.
+	// Defaults to DefaultRemoteArchiveMaxSize.
+	MaxSize int64
+	// Timeout is the max duration of the download, used when Client has none.
+	// Defaults to DefaultRemoteArchiveTimeout.
+	Timeout time.Duration
+}
+
+func ValidateRemoteArchive(ra *pb.RemoteArchive) error {
+	if ra == nil {
+		return status.Error(codes.InvalidArgument, "remote archive cannot be nil")
+	}
+
+	u, err := url.Parse(ra.Url)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid remote archive URL: %s", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return status.Error(codes.InvalidArgument, "remote archive URL must use either http or https scheme")
+	}
+
+	if u.Host == "" {
+		return status.Error(codes.InvalidArgument, "remote archive URL must have a host")
+	}
+
+	digest, err := hex.DecodeString(ra.Sha256)
+	if err != nil || len(digest) != sha256.Size {
+		return status.Error(codes.InvalidArgument, "remote archive SHA256 must be a hex-encoded SHA-256 digest")
+	}
+
+	return nil
+}
+
+// RemoteArchiveLocation returns the remote archive URL without user info and
+// query string, so it's safe to be shown in the build output.
+func RemoteArchiveLocation(ra *pb.RemoteArchive) string {
+	u, err := url.Parse(ra.Url)
+	if err != nil {
+		return ""
+	}
+
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+}
+
+func newRemoteArchiveClient(opts RemoteArchiveOptions) *http.Client {
+	var c http.Client
+	if opts.Client != nil {
+		c = *opts.Client
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = opts.Timeout
+		if c.Timeout <= 0 {
+			c.Timeout = DefaultRemoteArchiveTimeout
+		}
+	}
+
+	checkRedirect := c.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		for _, prev := range via {
+			if prev.URL.Scheme == "https" && req.URL.Scheme != "https" {
+				return errors.New("redirect from https to http is not allowed")
+			}
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+
+		if len(via) >= maxRemoteArchiveRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRemoteArchiveRedirects)
+		}
+
+		return nil
+	}
+
+	return &c
+}
+
+func DownloadRemoteArchive(ctx context.Context, ra *pb.RemoteArchive, opts RemoteArchiveOptions) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRemoteArchive(ra); err != nil {
+		return nil, err
+	}
+
+	c := newRemoteArchiveClient(opts)
+
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultRemoteArchiveMaxSize
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ra.Url, nil)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to create remote archive request: %s", err)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		// NOTE: url.Error holds the whole URL, which may have credentials
+		// (e.g. presigned URLs).
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+
+		return nil, status.Errorf(codes.Unavailable, "failed to download remote archive from %s: %s", RemoteArchiveLocation(ra), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to download remote archive: unexpected status code %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "remote archive exceeds the max size of %d bytes", maxSize)
+	}
+
+	var data bytes.Buffer
+	if resp.ContentLength > 0 {
+		data.Grow(int(resp.ContentLength))
+	}
+
+	h := sha256.New()
+
+	n, err := io.Copy(io.MultiWriter(&data, h), io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to download remote archive from %s: %s", RemoteArchiveLocation(ra), err)
+	}
+
+	if n > maxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "remote archive exceeds the max size of %d bytes", maxSize)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(ra.Sha256) {
+		return nil, status.Errorf(codes.InvalidArgument, "remote archive checksum mismatch: expected sha256 %s, got %s", strings.ToLower(ra.Sha256), got)
+	}
+
+	return data.Bytes(), nil
+}
@@ -5,8 +5,12 @@
 package build
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/url"
 	"strings"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -29,7 +33,9 @@ func (s *Server) Build(req *pb.BuildRequest, stream pb.Build_BuildServer) error
 	fmt.Println("Build RPC called")
 	defer fmt.Println("Finishing Build RPC call")
 
-	ctx := stream.Context()
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
 	if err := ctx.Err(); err != nil { // e.g. context deadline exceeded
 		return err
 	}
@@ -38,7 +44,12 @@ func (s *Server) Build(req *pb.BuildRequest, stream pb.Build_BuildServer) error
 		return err
 	}
 
-	w := &BuildResponseOutputWriter{stream: stream}
+	// NOTE: there's no point in going on when the output can't reach the
+	// client anymore, so the build is canceled.
+	out := NewBuildResponseOutputWriter(stream, func(error) { cancel() })
+	w := newOutputWriter(out, requestSecrets(req), progressTimestamp(req.ProgressTimestamp, time.Now()), req.ProgressMode == pb.ProgressMode_PROGRESS_MODE_RAWJSON)
+	defer w.Flush()
+
 	fmt.Fprintln(w, "---> Starting container image build")
 
 	appFiles, err := s.b.Build(ctx, req, w)
@@ -47,13 +58,107 @@ func (s *Server) Build(req *pb.BuildRequest, stream pb.Build_BuildServer) error
 	}
 
 	if appFiles != nil {
-		if err = stream.Send(&pb.BuildResponse{Data: &pb.BuildResponse_TsuruConfig{TsuruConfig: appFiles}}); err != nil {
+		if err = fillStructuredTsuruConfig(appFiles, w); err != nil {
+			return err
+		}
+
+		if err = w.SendTsuruConfig(appFiles); err != nil {
 			return status.Errorf(codes.Unknown, "failed to send tsuru app files: %s", err)
 		}
 	}
 
 	fmt.Fprintln(w, "--> Container image build finished")
 
+	return w.Flush()
+}
+
+// progressTimestamp returns the prefix of output lines, if any.
+func progressTimestamp(t pb.ProgressTimestamp, start time.Time) func() string {
+	switch t {
+	case pb.ProgressTimestamp_PROGRESS_TIMESTAMP_ELAPSED:
+		return func() string { return fmt.Sprintf("[%8.3fs] ", time.Since(start).Seconds()) }
+
+	case pb.ProgressTimestamp_PROGRESS_TIMESTAMP_WALL_CLOCK:
+		return func() string { return time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00 ") }
+	}
+
+	return nil
+}
+
+// requestSecrets returns the values of r which must not show up in the build
+// output: app env vars and the credentials of remote archive URL.
+func requestSecrets(r *pb.BuildRequest) []string {
+	var secrets []string
+
+	if app := r.App; app != nil {
+		for _, v := range app.EnvVars {
+			secrets = append(secrets, v)
+		}
+
+		for _, v := range app.BuildEnvVars {
+			secrets = append(secrets, v)
+		}
+	}
+
+	if ra := r.RemoteArchive; ra != nil {
+		if u, err := url.Parse(ra.Url); err == nil {
+			if password, ok := u.User.Password(); ok {
+				secrets = append(secrets, password)
+			}
+
+			for _, values := range u.Query() { // e.g. signatures of pre-signed URLs
+				secrets = append(secrets, values...)
+			}
+		}
+	}
+
+	return secrets
+}
+
+func (s *Server) PreviewBuild(ctx context.Context, req *pb.BuildRequest) (*pb.BuildPreview, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := validateBuildRequest(req); err != nil {
+		return nil, err
+	}
+
+	preview, err := s.b.Preview(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if tc := preview.TsuruConfig; tc != nil {
+		if err = fillStructuredTsuruConfig(tc, io.Discard); err != nil {
+			return nil, err
+		}
+	}
+
+	return preview, nil
+}
+
+// fillStructuredTsuruConfig parses both Procfile and tsuru.yaml, so callers
+// don't need to do that again.
+func fillStructuredTsuruConfig(tc *pb.TsuruConfig, w io.Writer) error {
+	processes, problems := ParseProcfile(tc.Procfile)
+	for _, p := range problems {
+		fmt.Fprintln(w, "Ignoring invalid Procfile entry:", p)
+	}
+
+	tc.Processes = processes
+
+	if tc.TsuruYaml == "" {
+		return nil
+	}
+
+	tsuruYaml, _, err := ParseTsuruYaml(tc.TsuruYaml) // warnings were already printed by the builder
+	if err != nil {
+		return err
+	}
+
+	tc.TsuruYamlData = TsuruYamlDataToProto(tsuruYaml)
+
 	return nil
 }
 
@@ -89,6 +194,42 @@ func validateBuildRequest(r *pb.BuildRequest) error {
 		return status.Error(codes.InvalidArgument, "platform cannot be nil")
 	}
 
+	if err := validateAppName(r.App); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := validateBuildEnvVars(r.App); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if _, found := pb.CachePolicy_name[int32(r.CachePolicy)]; !found {
+		return status.Error(codes.InvalidArgument, "invalid cache policy")
+	}
+
+	if _, found := pb.ProgressMode_name[int32(r.ProgressMode)]; !found {
+		return status.Error(codes.InvalidArgument, "invalid progress mode")
+	}
+
+	if _, found := pb.ProgressTimestamp_name[int32(r.ProgressTimestamp)]; !found {
+		return status.Error(codes.InvalidArgument, "invalid progress timestamp")
+	}
+
+	if r.ProgressMode == pb.ProgressMode_PROGRESS_MODE_RAWJSON && r.ProgressTimestamp != pb.ProgressTimestamp_PROGRESS_TIMESTAMP_NONE {
+		return status.Error(codes.InvalidArgument, "progress timestamp is not supported in rawjson progress mode")
+	}
+
+	if epoch := r.SourceDateEpoch; epoch != nil && (epoch.CheckValid() != nil || epoch.Seconds < 0) {
+		return status.Error(codes.InvalidArgument, "source date epoch must be a valid timestamp since Unix epoch")
+	}
+
+	if err := validateBuildHookOptions(r.BuildHooks); err != nil {
+		return err
+	}
+
+	if err := ValidateTsuruConfigSearch(NewTsuruConfigSearch(r.TsuruConfigSearch)); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	switch kind {
 	case "BUILD_KIND_APP_BUILD_WITH_SOURCE_UPLOAD":
 		if err := validateBuildRequestFromSourceData(r); err != nil {
@@ -107,11 +248,35 @@ func validateBuildRequest(r *pb.BuildRequest) error {
 	return nil
 }
 
+func validateBuildHookOptions(opts *pb.BuildHookOptions) error {
+	if opts == nil || opts.Timeout == nil {
+		return nil
+	}
+
+	if opts.Timeout.CheckValid() != nil || opts.Timeout.AsDuration() <= 0 {
+		return status.Error(codes.InvalidArgument, "build hook timeout must be a positive duration")
+	}
+
+	if !opts.SeparateSteps {
+		return status.Error(codes.InvalidArgument, "build hook timeout requires separate steps")
+	}
+
+	return nil
+}
+
 func validateBuildRequestFromSourceData(r *pb.BuildRequest) error {
 	if r.SourceImage == "" {
 		return status.Error(codes.InvalidArgument, "source image cannot be empty")
 	}
 
+	if r.RemoteArchive != nil {
+		if len(r.Data) > 0 {
+			return status.Error(codes.InvalidArgument, "app source data and remote archive are mutually exclusive")
+		}
+
+		return ValidateRemoteArchive(r.RemoteArchive)
+	}
+
 	if len(r.Data) == 0 {
 		return status.Error(codes.InvalidArgument, "app source data not provided")
 	}
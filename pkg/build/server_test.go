@@ -7,19 +7,26 @@ package build_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/moby/buildkit/client"
+	"github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	. "github.com/tsuru/deploy-agent/pkg/build"
 	"github.com/tsuru/deploy-agent/pkg/build/fake"
@@ -144,6 +151,152 @@ func TestBuild(t *testing.T) {
 			},
 		},
 
+		"tsuru config search w/ relative dir": {
+			req: &pb.BuildRequest{
+				SourceImage:       "registry.example.com/my-app:v1",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_IMAGE,
+				TsuruConfigSearch: &pb.TsuruConfigSearch{Dirs: []string{"srv/app"}},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, `tsuru config search dir "srv/app" must be an absolute path`).Error())
+			},
+		},
+
+		"invalid cache policy": {
+			req: &pb.BuildRequest{
+				SourceImage:       "registry.example.com/my-app:v1",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_IMAGE,
+				CachePolicy:       pb.CachePolicy(42),
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "invalid cache policy").Error())
+			},
+		},
+
+		"negative source date epoch": {
+			req: &pb.BuildRequest{
+				SourceImage:       "registry.example.com/my-app:v1",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_IMAGE,
+				SourceDateEpoch:   &timestamppb.Timestamp{Seconds: -1},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "source date epoch must be a valid timestamp since Unix epoch").Error())
+			},
+		},
+
+		"invalid app name": {
+			req: &pb.BuildRequest{
+				SourceImage:       "tsuru/python:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app,target=/etc"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_SOURCE_UPLOAD,
+				Data:              []byte("fake data :P"),
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, `app name "my-app,target=/etc" must have only lowercase letters, digits and - characters (up to 63), starting with a letter`).Error())
+			},
+		},
+
+		"invalid build env var name": {
+			req: &pb.BuildRequest{
+				SourceImage:       "registry.example.com/my-app:v1",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app", BuildEnvVars: map[string]string{"NPM-TOKEN": "npm_abc"}},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_IMAGE,
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, `build env var name "NPM-TOKEN" must have only letters, digits and _ characters, not starting with a digit`).Error())
+			},
+		},
+
+		"build hook timeout w/o separate steps": {
+			req: &pb.BuildRequest{
+				SourceImage:       "tsuru/python:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_SOURCE_UPLOAD,
+				Data:              []byte("..."),
+				BuildHooks:        &pb.BuildHookOptions{Timeout: durationpb.New(time.Minute)},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "build hook timeout requires separate steps").Error())
+			},
+		},
+
+		"non-positive build hook timeout": {
+			req: &pb.BuildRequest{
+				SourceImage:       "tsuru/python:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_SOURCE_UPLOAD,
+				Data:              []byte("..."),
+				BuildHooks:        &pb.BuildHookOptions{SeparateSteps: true, Timeout: durationpb.New(0)},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "build hook timeout must be a positive duration").Error())
+			},
+		},
+
+		"deploy from source code, both app source data and remote archive": {
+			req: &pb.BuildRequest{
+				SourceImage:       "tsuru/scratch:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_SOURCE_UPLOAD,
+				Data:              []byte("fake data :P"),
+				RemoteArchive:     &pb.RemoteArchive{Url: "https://artifacts.example.com/my-app.tar.gz", Sha256: "c5b4b2d3fc3e1d2d7f5c8d1b5c0cf1c0c4b5d0c3c1a2b3c4d5e6f7a8b9c0d1e2"},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "app source data and remote archive are mutually exclusive").Error())
+			},
+		},
+
+		"deploy from source code, remote archive w/ invalid checksum": {
+			req: &pb.BuildRequest{
+				SourceImage:       "tsuru/scratch:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App:               &pb.TsuruApp{Name: "my-app"},
+				Kind:              pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_SOURCE_UPLOAD,
+				RemoteArchive:     &pb.RemoteArchive{Url: "https://artifacts.example.com/my-app.tar.gz"},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "remote archive SHA256 must be a hex-encoded SHA-256 digest").Error())
+			},
+		},
+
 		"when builder returns an error": {
 			builder: &fake.FakeBuilder{
 				OnBuild: func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error) {
@@ -172,7 +325,7 @@ func TestBuild(t *testing.T) {
 					assert.NotNil(t, w)
 					fmt.Fprintln(w, "--- EXECUTING BUILD ---")
 					return &pb.TsuruConfig{
-						Procfile:  "web: ./path/to/server.sh --addr :${PORT}",
+						Procfile:  "web: ./path/to/server.sh --addr :${PORT}\nworker ./path/to/worker.sh\n",
 						TsuruYaml: "healthcheck:\n  path: /healthz",
 					}, nil
 				},
@@ -190,8 +343,18 @@ func TestBuild(t *testing.T) {
 				tsuruConfig, output, err := readResponse(t, stream)
 				require.NoError(t, err)
 				require.NotNil(t, tsuruConfig)
-				assert.Equal(t, &pb.TsuruConfig{Procfile: "web: ./path/to/server.sh --addr :${PORT}", TsuruYaml: "healthcheck:\n  path: /healthz"}, tsuruConfig)
+				assert.Equal(t, &pb.TsuruConfig{
+					Procfile:  "web: ./path/to/server.sh --addr :${PORT}\nworker ./path/to/worker.sh\n",
+					TsuruYaml: "healthcheck:\n  path: /healthz",
+					Processes: []*pb.TsuruProcess{
+						{Name: "web", Command: "./path/to/server.sh --addr :${PORT}"},
+					},
+					TsuruYamlData: &pb.TsuruYamlData{
+						Healthcheck: &pb.TsuruYamlHealthcheck{Path: "/healthz"},
+					},
+				}, tsuruConfig)
 				assert.Regexp(t, `(.*)--- EXECUTING BUILD ---(.*)`, output)
+				assert.Contains(t, output, `Ignoring invalid Procfile entry: line 2: invalid process definition "worker ./path/to/worker.sh", must be in the <name>: <command> format`)
 			},
 		},
 
@@ -248,6 +411,146 @@ func TestBuild(t *testing.T) {
 			},
 		},
 
+		"secrets redacted from output": {
+			builder: &fake.FakeBuilder{
+				OnBuild: func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error) {
+					fmt.Fprint(w, "#5 0.123 DATABASE_PASSWORD=s3cr")
+					fmt.Fprintln(w, "3t-p4ss DEBUG=true")
+					fmt.Fprintln(w, "#5 0.124 NPM_TOKEN=npm_abc123")
+					fmt.Fprintln(w, "#5 0.125 downloading from https://artifacts.example.com/my-app.tar.gz?signature=abcdef123456")
+					return nil, nil
+				},
+			},
+			req: &pb.BuildRequest{
+				SourceImage:       "tsuru/python:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App: &pb.TsuruApp{
+					Name:         "my-app",
+					EnvVars:      map[string]string{"DATABASE_PASSWORD": "s3cr3t-p4ss", "DEBUG": "true"},
+					BuildEnvVars: map[string]string{"NPM_TOKEN": "npm_abc123"},
+				},
+				Kind:          pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_SOURCE_UPLOAD,
+				RemoteArchive: &pb.RemoteArchive{Url: "https://artifacts.example.com/my-app.tar.gz?signature=abcdef123456", Sha256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, output, err := readResponse(t, stream)
+				require.NoError(t, err)
+				assert.Contains(t, output, "#5 0.123 DATABASE_PASSWORD=*** DEBUG=true\n")
+				assert.Contains(t, output, "#5 0.124 NPM_TOKEN=***\n")
+				assert.Contains(t, output, "#5 0.125 downloading from https://artifacts.example.com/my-app.tar.gz?signature=***\n")
+				assert.NotContains(t, output, "s3cr3t-p4ss")
+			},
+		},
+
+		"rawjson mode w/ secrets in logs and messages": {
+			builder: &fake.FakeBuilder{
+				OnBuild: func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error) {
+					enc := json.NewEncoder(w)
+					vertex := digest.FromString("RUN env")
+					now := time.Now()
+
+					fmt.Fprintln(w, "Downloading app source data with NPM_TOKEN=npm_abc123")
+
+					// the secret is split across solve statuses
+					require.NoError(t, enc.Encode(&client.SolveStatus{Logs: []*client.VertexLog{{Vertex: vertex, Data: []byte("DATABASE_PASSWORD=s3cr3t")}}}))
+					require.NoError(t, enc.Encode(&client.SolveStatus{Logs: []*client.VertexLog{{Vertex: vertex, Data: []byte("-p4ss\n")}}}))
+					require.NoError(t, enc.Encode(&client.SolveStatus{Logs: []*client.VertexLog{{Vertex: vertex, Data: []byte("NPM_TOKEN=npm_abc123")}}}))
+					require.NoError(t, enc.Encode(&client.SolveStatus{Vertexes: []*client.Vertex{{Digest: vertex, Name: "RUN env", Started: &now, Completed: &now}}}))
+					return nil, nil
+				},
+			},
+			req: &pb.BuildRequest{
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				App: &pb.TsuruApp{
+					Name:    "my-app",
+					EnvVars: map[string]string{"DATABASE_PASSWORD": "s3cr3t-p4ss", "NPM_TOKEN": "npm_abc123"},
+				},
+				Kind:          pb.BuildKind_BUILD_KIND_APP_DEPLOY_WITH_CONTAINER_FILE,
+				Containerfile: `FROM tsuru/scratch:latest`,
+				ProgressMode:  pb.ProgressMode_PROGRESS_MODE_RAWJSON,
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, output, err := readResponse(t, stream)
+				require.NoError(t, err)
+
+				var logs bytes.Buffer
+				var messages []string
+				for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+					require.True(t, json.Valid([]byte(line)), "not a JSON line: %s", line)
+
+					var m RawJSONMessage
+					require.NoError(t, json.Unmarshal([]byte(line), &m))
+					if m.Message != "" {
+						messages = append(messages, m.Message)
+						continue
+					}
+
+					var st client.SolveStatus
+					require.NoError(t, json.Unmarshal([]byte(line), &st))
+					for _, l := range st.Logs {
+						logs.Write(l.Data)
+					}
+				}
+
+				assert.Equal(t, "DATABASE_PASSWORD=***\nNPM_TOKEN=***", logs.String())
+				assert.Equal(t, []string{
+					"---> Starting container image build",
+					"Downloading app source data with NPM_TOKEN=***",
+					"--> Container image build finished",
+				}, messages)
+			},
+		},
+
+		"progress timestamp on rawjson mode": {
+			req: &pb.BuildRequest{
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				Platform:          &pb.TsuruPlatform{Name: "my-platform"},
+				Kind:              pb.BuildKind_BUILD_KIND_PLATFORM_WITH_CONTAINER_FILE,
+				Containerfile:     `FROM tsuru/scratch:latest`,
+				ProgressMode:      pb.ProgressMode_PROGRESS_MODE_RAWJSON,
+				ProgressTimestamp: pb.ProgressTimestamp_PROGRESS_TIMESTAMP_ELAPSED,
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, _, err = readResponse(t, stream)
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "progress timestamp is not supported in rawjson progress mode").Error())
+			},
+		},
+
+		"output w/ elapsed timestamps": {
+			builder: &fake.FakeBuilder{
+				OnBuild: func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error) {
+					fmt.Fprint(w, "#1 [internal] load ")
+					fmt.Fprintln(w, "build definition from Dockerfile")
+					fmt.Fprintln(w, "#1 DONE 0.0s")
+					return nil, nil
+				},
+			},
+			req: &pb.BuildRequest{
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				Platform:          &pb.TsuruPlatform{Name: "my-platform"},
+				Kind:              pb.BuildKind_BUILD_KIND_PLATFORM_WITH_CONTAINER_FILE,
+				Containerfile:     `FROM tsuru/scratch:latest`,
+				ProgressTimestamp: pb.ProgressTimestamp_PROGRESS_TIMESTAMP_ELAPSED,
+			},
+			assert: func(t *testing.T, stream pb.Build_BuildClient, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, stream)
+				_, output, err := readResponse(t, stream)
+				require.NoError(t, err)
+				assert.Regexp(t, `^\[ +\d+\.\d{3}s\] ---> Starting container image build
+\[ +\d+\.\d{3}s\] #1 \[internal\] load build definition from Dockerfile
+\[ +\d+\.\d{3}s\] #1 DONE 0\.0s
+\[ +\d+\.\d{3}s\] --> Container image build finished
+$`, output)
+			},
+		},
+
 		"app deploy with containerfile, empty containerfile": {
 			req: &pb.BuildRequest{
 				SourceImage:       "...",
@@ -282,6 +585,93 @@ func TestBuild(t *testing.T) {
 	}
 }
 
+func TestPreviewBuild(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		builder Builder
+		req     *pb.BuildRequest
+		assert  func(t *testing.T, preview *pb.BuildPreview, err error)
+	}{
+		"invalid request": {
+			req: &pb.BuildRequest{},
+			assert: func(t *testing.T, _ *pb.BuildPreview, err error) {
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "either source image or containerfile must be set").Error())
+			},
+		},
+
+		"builder error": {
+			builder: &fake.FakeBuilder{
+				OnPreview: func(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error) {
+					return nil, status.Error(codes.InvalidArgument, "invalid archive: archive must be either tar, tar.gz, tar.zst or zip")
+				},
+			},
+			req: &pb.BuildRequest{
+				Kind:              pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_SOURCE_UPLOAD,
+				App:               &pb.TsuruApp{Name: "my-app"},
+				SourceImage:       "tsuru/python:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				Data:              []byte("not an archive"),
+			},
+			assert: func(t *testing.T, _ *pb.BuildPreview, err error) {
+				assert.EqualError(t, err, status.Error(codes.InvalidArgument, "invalid archive: archive must be either tar, tar.gz, tar.zst or zip").Error())
+			},
+		},
+
+		"app source preview": {
+			builder: &fake.FakeBuilder{
+				OnBuild: func(ctx context.Context, r *pb.BuildRequest, w io.Writer) (*pb.TsuruConfig, error) {
+					return nil, errors.New("build must not be called")
+				},
+				OnPreview: func(ctx context.Context, r *pb.BuildRequest) (*pb.BuildPreview, error) {
+					return &pb.BuildPreview{
+						Containerfile: "FROM tsuru/python:latest\n",
+						TsuruConfig: &pb.TsuruConfig{
+							Procfile:  "web: gunicorn app:app\n",
+							TsuruYaml: "hooks:\n  build:\n  - make assets\n",
+						},
+						BuildHooks:    []string{"make assets"},
+						ContextFiles:  []string{"Procfile", "app.py", "tsuru.yaml"},
+						FrontendAttrs: map[string]string{"build-arg:tsuru_deploy_cache": "1700000000"},
+					}, nil
+				},
+			},
+			req: &pb.BuildRequest{
+				Kind:              pb.BuildKind_BUILD_KIND_APP_BUILD_WITH_SOURCE_UPLOAD,
+				App:               &pb.TsuruApp{Name: "my-app"},
+				SourceImage:       "tsuru/python:latest",
+				DestinationImages: []string{"registry.example.com/tsuru/app-my-app:v1"},
+				Data:              []byte("..."),
+			},
+			assert: func(t *testing.T, preview *pb.BuildPreview, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "FROM tsuru/python:latest\n", preview.Containerfile)
+				assert.Equal(t, []string{"make assets"}, preview.BuildHooks)
+				assert.Equal(t, []string{"Procfile", "app.py", "tsuru.yaml"}, preview.ContextFiles)
+				assert.Equal(t, map[string]string{"build-arg:tsuru_deploy_cache": "1700000000"}, preview.FrontendAttrs)
+				require.NotNil(t, preview.TsuruConfig)
+				require.Len(t, preview.TsuruConfig.Processes, 1)
+				assert.Equal(t, "web", preview.TsuruConfig.Processes[0].Name)
+				require.NotNil(t, preview.TsuruConfig.TsuruYamlData)
+				assert.Equal(t, []string{"make assets"}, preview.TsuruConfig.TsuruYamlData.Hooks.Build)
+			},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			serverAddr := setupServer(t, NewServer(tt.builder))
+			c := setupClient(t, serverAddr)
+
+			preview, err := c.PreviewBuild(context.Background(), tt.req)
+			tt.assert(t, preview, err)
+		})
+	}
+}
+
 func setupServer(t *testing.T, bs pb.BuildServer) string {
 	t.Helper()
 
@@ -295,6 +685,9 @@ func setupServer(t *testing.T, bs pb.BuildServer) string {
 
 	go func() {
 		nerr := s.Serve(l)
+		if errors.Is(nerr, grpc.ErrServerStopped) { // server stopped before start serving
+			return
+		}
 		require.NoError(t, nerr)
 	}()
 
@@ -0,0 +1,325 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+var (
+	tsuruYamlHealthcheckSchemes  = []string{"http", "https"}
+	tsuruYamlHealthcheckMethods  = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	tsuruYamlKubernetesProtocols = []string{"TCP", "UDP", "SCTP"}
+
+	yamlErrorRegexp = regexp.MustCompile(`^line (\d+): (.*?)(?: in type \S+)?$`)
+)
+
+type TsuruYamlProblem struct {
+	Line    int
+	Message string
+}
+
+func (p TsuruYamlProblem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// TsuruYamlValidationError lists every problem found in the tsuru.yaml. It's
+// reported as codes.InvalidArgument to gRPC clients.
+type TsuruYamlValidationError struct {
+	Problems []TsuruYamlProblem
+}
+
+func (e *TsuruYamlValidationError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		problems = append(problems, p.String())
+	}
+
+	return "invalid tsuru.yaml: " + strings.Join(problems, "; ")
+}
+
+func (e *TsuruYamlValidationError) GRPCStatus() *status.Status {
+	return status.New(codes.InvalidArgument, e.Error())
+}
+
+// tsuruYamlDocument captures the top-level fields unknown by TsuruYamlData,
+// which is only a partial copy of Tsuru's types, so strict decoding applies
+// to the known fields only.
+type tsuruYamlDocument struct {
+	TsuruYamlData `yaml:",inline"`
+	Unknown       map[string]yaml.Node `yaml:",inline"`
+}
+
+// ParseTsuruYaml decodes the tsuru.yaml strictly, i.e. unknown fields and
+// fields with wrong types are rejected, and validates its semantics. Unknown
+// top-level fields are accepted though, and returned as warnings since they
+// may be supported by Tsuru nonetheless.
+func ParseTsuruYaml(data string) (*TsuruYamlData, []TsuruYamlProblem, error) {
+	var doc tsuruYamlDocument
+	if strings.TrimSpace(data) == "" {
+		return &doc.TsuruYamlData, nil, nil
+	}
+
+	var problems []TsuruYamlProblem
+
+	d := yaml.NewDecoder(strings.NewReader(data))
+	d.KnownFields(true)
+
+	err := d.Decode(&doc)
+
+	var typeErr *yaml.TypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+
+	case errors.As(err, &typeErr): // decoding goes on despite those errors
+		for _, e := range typeErr.Errors {
+			problems = append(problems, newTsuruYamlProblem(e))
+		}
+
+	default: // malformed YAML
+		return nil, nil, &TsuruYamlValidationError{Problems: []TsuruYamlProblem{newTsuruYamlProblem(strings.TrimPrefix(err.Error(), "yaml: "))}}
+	}
+
+	var root yaml.Node
+	if err = yaml.Unmarshal([]byte(data), &root); err != nil {
+		return nil, nil, &TsuruYamlValidationError{Problems: []TsuruYamlProblem{newTsuruYamlProblem(strings.TrimPrefix(err.Error(), "yaml: "))}}
+	}
+
+	tsuruYaml := &doc.TsuruYamlData
+
+	problems = append(problems, validateTsuruYaml(tsuruYaml, &root)...)
+
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+		return nil, nil, &TsuruYamlValidationError{Problems: problems}
+	}
+
+	var warnings []TsuruYamlProblem
+	for _, field := range sortedKeys(doc.Unknown) {
+		warnings = append(warnings, TsuruYamlProblem{
+			Line:    yamlNodeLine(&root, field),
+			Message: fmt.Sprintf("unknown field %q", field),
+		})
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
+
+	return tsuruYaml, warnings, nil
+}
+
+func newTsuruYamlProblem(s string) TsuruYamlProblem {
+	matches := yamlErrorRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return TsuruYamlProblem{Message: s}
+	}
+
+	line, _ := strconv.Atoi(matches[1])
+	return TsuruYamlProblem{Line: line, Message: matches[2]}
+}
+
+func validateTsuruYaml(t *TsuruYamlData, root *yaml.Node) []TsuruYamlProblem {
+	var problems []TsuruYamlProblem
+
+	if hc := t.Healthcheck; hc != nil {
+		if hc.Status != 0 && (hc.Status < 100 || hc.Status > 599) {
+			problems = append(problems, TsuruYamlProblem{
+				Line:    yamlNodeLine(root, "healthcheck", "status"),
+				Message: fmt.Sprintf("healthcheck status %d must be between 100 and 599", hc.Status),
+			})
+		}
+
+		if hc.Scheme != "" && !containsFold(tsuruYamlHealthcheckSchemes, hc.Scheme) {
+			problems = append(problems, TsuruYamlProblem{
+				Line:    yamlNodeLine(root, "healthcheck", "scheme"),
+				Message: fmt.Sprintf("healthcheck scheme %q must be one of %s", hc.Scheme, strings.Join(tsuruYamlHealthcheckSchemes, ", ")),
+			})
+		}
+
+		if hc.Method != "" && !containsFold(tsuruYamlHealthcheckMethods, hc.Method) {
+			problems = append(problems, TsuruYamlProblem{
+				Line:    yamlNodeLine(root, "healthcheck", "method"),
+				Message: fmt.Sprintf("healthcheck method %q must be one of %s", hc.Method, strings.Join(tsuruYamlHealthcheckMethods, ", ")),
+			})
+		}
+	}
+
+	if k := t.Kubernetes; k != nil {
+		for _, group := range sortedKeys(k.Groups) {
+			for _, process := range sortedKeys(k.Groups[group]) {
+				names := make(map[string]bool)
+
+				for i, port := range k.Groups[group][process].Ports {
+					path := []string{"kubernetes", "groups", group, process, "ports", strconv.Itoa(i)}
+
+					if port.Protocol != "" && !containsFold(tsuruYamlKubernetesProtocols, port.Protocol) {
+						problems = append(problems, TsuruYamlProblem{
+							Line:    yamlNodeLine(root, append(path, "protocol")...),
+							Message: fmt.Sprintf("protocol %q of process %q must be one of %s", port.Protocol, process, strings.Join(tsuruYamlKubernetesProtocols, ", ")),
+						})
+					}
+
+					if port.Name == "" {
+						continue
+					}
+
+					if names[port.Name] {
+						problems = append(problems, TsuruYamlProblem{
+							Line:    yamlNodeLine(root, append(path, "name")...),
+							Message: fmt.Sprintf("duplicate port name %q in process %q", port.Name, process),
+						})
+					}
+
+					names[port.Name] = true
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// yamlNodeLine returns the line of the deepest node found following path,
+// where path items are either mapping keys or sequence indexes.
+func yamlNodeLine(n *yaml.Node, path ...string) int {
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+
+	line := n.Line
+
+	for _, p := range path {
+		var next *yaml.Node
+
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == p {
+					line, next = n.Content[i].Line, n.Content[i+1]
+					break
+				}
+			}
+
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(p); err == nil && i >= 0 && i < len(n.Content) {
+				next = n.Content[i]
+				line = next.Line
+			}
+		}
+
+		if next == nil {
+			break
+		}
+
+		n = next
+	}
+
+	return line
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func TsuruYamlDataToProto(t *TsuruYamlData) *pb.TsuruYamlData {
+	if t == nil {
+		return nil
+	}
+
+	var data pb.TsuruYamlData
+
+	if h := t.Hooks; h != nil {
+		data.Hooks = &pb.TsuruYamlHooks{
+			Build: h.Build,
+		}
+
+		if len(h.Restart.Before) > 0 || len(h.Restart.After) > 0 {
+			data.Hooks.Restart = &pb.TsuruYamlRestartHooks{
+				Before: h.Restart.Before,
+				After:  h.Restart.After,
+			}
+		}
+	}
+
+	if hc := t.Healthcheck; hc != nil {
+		data.Healthcheck = &pb.TsuruYamlHealthcheck{
+			Headers:              hc.Headers,
+			Path:                 hc.Path,
+			Method:               hc.Method,
+			Scheme:               hc.Scheme,
+			Match:                hc.Match,
+			RouterBody:           hc.RouterBody,
+			Command:              hc.Command,
+			Status:               int32(hc.Status),
+			AllowedFailures:      int32(hc.AllowedFailures),
+			IntervalSeconds:      int32(hc.IntervalSeconds),
+			TimeoutSeconds:       int32(hc.TimeoutSeconds),
+			DeployTimeoutSeconds: int32(hc.DeployTimeoutSeconds),
+			UseInRouter:          hc.UseInRouter,
+			ForceRestart:         hc.ForceRestart,
+		}
+	}
+
+	if k := t.Kubernetes; k != nil {
+		data.Kubernetes = &pb.TsuruYamlKubernetesConfig{}
+
+		for name, group := range k.Groups {
+			if data.Kubernetes.Groups == nil {
+				data.Kubernetes.Groups = make(map[string]*pb.TsuruYamlKubernetesGroup)
+			}
+
+			g := &pb.TsuruYamlKubernetesGroup{Processes: make(map[string]*pb.TsuruYamlKubernetesProcessConfig)}
+
+			for process, config := range group {
+				pc := &pb.TsuruYamlKubernetesProcessConfig{}
+				for _, port := range config.Ports {
+					pc.Ports = append(pc.Ports, &pb.TsuruYamlKubernetesProcessPortConfig{
+						Name:       port.Name,
+						Protocol:   port.Protocol,
+						Port:       int32(port.Port),
+						TargetPort: int32(port.TargetPort),
+					})
+				}
+
+				g.Processes[process] = pc
+			}
+
+			data.Kubernetes.Groups[name] = g
+		}
+	}
+
+	return &data
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/tsuru/deploy-agent/pkg/build"
+	pb "github.com/tsuru/deploy-agent/pkg/build/grpc_build_v1"
+)
+
+func TestParseTsuruYaml(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		data             string
+		expected         *TsuruYamlData
+		expectedWarnings []TsuruYamlProblem
+		expectedProblems []TsuruYamlProblem
+	}{
+		"empty tsuru.yaml": {
+			expected: &TsuruYamlData{},
+		},
+
+		"valid tsuru.yaml": {
+			data: `hooks:
+  build:
+  - make build
+healthcheck:
+  path: /healthz
+  scheme: HTTPS
+  method: get
+  status: 204
+kubernetes:
+  groups:
+    my-app:
+      web:
+        ports:
+        - name: http
+          port: 80
+          target_port: 8888
+          protocol: tcp
+        - name: grpc
+          port: 9000
+`,
+			expected: &TsuruYamlData{
+				Hooks: &TsuruYamlHooks{Build: []string{"make build"}},
+				Healthcheck: &TsuruYamlHealthcheck{
+					Path:   "/healthz",
+					Scheme: "HTTPS",
+					Method: "get",
+					Status: 204,
+				},
+				Kubernetes: &TsuruYamlKubernetesConfig{
+					Groups: map[string]TsuruYamlKubernetesGroup{
+						"my-app": {
+							"web": {
+								Ports: []TsuruYamlKubernetesProcessPortConfig{
+									{Name: "http", Port: 80, TargetPort: 8888, Protocol: "tcp"},
+									{Name: "grpc", Port: 9000},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		"unknown top-level fields": {
+			data: `startupcheck:
+  path: /ready
+hooks:
+  build:
+  - make build
+processes:
+- name: web
+`,
+			expected: &TsuruYamlData{Hooks: &TsuruYamlHooks{Build: []string{"make build"}}},
+			expectedWarnings: []TsuruYamlProblem{
+				{Line: 1, Message: `unknown field "startupcheck"`},
+				{Line: 6, Message: `unknown field "processes"`},
+			},
+		},
+
+		"malformed YAML": {
+			data: "healthcheck:\n  path: /\n path: /healthz\n",
+			expectedProblems: []TsuruYamlProblem{
+				{Line: 2, Message: "did not find expected key"},
+			},
+		},
+
+		"unknown fields, wrong types and semantic problems": {
+			data: `healthcheck:
+  pth: /healthz
+  status: 1000
+  scheme: ftp
+  method: FETCH
+  interval_seconds: three
+kubernetes:
+  groups:
+    my-app:
+      web:
+        ports:
+        - name: http
+          port: 80
+          protocol: HTTP
+        - name: http
+          port: 8080
+`,
+			expectedProblems: []TsuruYamlProblem{
+				{Line: 2, Message: "field pth not found"},
+				{Line: 3, Message: "healthcheck status 1000 must be between 100 and 599"},
+				{Line: 4, Message: `healthcheck scheme "ftp" must be one of http, https`},
+				{Line: 5, Message: `healthcheck method "FETCH" must be one of GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS`},
+				{Line: 6, Message: "cannot unmarshal !!str `three` into int"},
+				{Line: 14, Message: `protocol "HTTP" of process "web" must be one of TCP, UDP, SCTP`},
+				{Line: 15, Message: `duplicate port name "http" in process "web"`},
+			},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, warnings, err := ParseTsuruYaml(tt.data)
+			if len(tt.expectedProblems) > 0 {
+				require.Error(t, err)
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+				var verr *TsuruYamlValidationError
+				require.ErrorAs(t, err, &verr)
+				assert.Equal(t, tt.expectedProblems, verr.Problems)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+			assert.Equal(t, tt.expectedWarnings, warnings)
+		})
+	}
+}
+
+func TestTsuruYamlValidationError_Error(t *testing.T) {
+	err := &TsuruYamlValidationError{Problems: []TsuruYamlProblem{
+		{Line: 1, Message: "field healthchek not found"},
+		{Line: 4, Message: "healthcheck status 1000 must be between 100 and 599"},
+	}}
+
+	assert.EqualError(t, err, "invalid tsuru.yaml: line 1: field healthchek not found; line 4: healthcheck status 1000 must be between 100 and 599")
+}
+
+func TestTsuruYamlDataToProto(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, TsuruYamlDataToProto(nil))
+
+	got := TsuruYamlDataToProto(&TsuruYamlData{
+		Hooks: &TsuruYamlHooks{
+			Build:   []string{"make build"},
+			Restart: TsuruYamlRestartHooks{Before: []string{"./migrate.sh"}},
+		},
+		Healthcheck: &TsuruYamlHealthcheck{
+			Headers:         map[string]string{"Host": "my-app.example.com"},
+			Path:            "/healthz",
+			Status:          200,
+			IntervalSeconds: 3,
+			UseInRouter:     true,
+		},
+		Kubernetes: &TsuruYamlKubernetesConfig{
+			Groups: map[string]TsuruYamlKubernetesGroup{
+				"my-app": {
+					"web": {Ports: []TsuruYamlKubernetesProcessPortConfig{{Name: "http", Protocol: "TCP", Port: 80, TargetPort: 8888}}},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, &pb.TsuruYamlData{
+		Hooks: &pb.TsuruYamlHooks{
+			Build:   []string{"make build"},
+			Restart: &pb.TsuruYamlRestartHooks{Before: []string{"./migrate.sh"}},
+		},
+		Healthcheck: &pb.TsuruYamlHealthcheck{
+			Headers:         map[string]string{"Host": "my-app.example.com"},
+			Path:            "/healthz",
+			Status:          200,
+			IntervalSeconds: 3,
+			UseInRouter:     true,
+		},
+		Kubernetes: &pb.TsuruYamlKubernetesConfig{
+			Groups: map[string]*pb.TsuruYamlKubernetesGroup{
+				"my-app": {
+					Processes: map[string]*pb.TsuruYamlKubernetesProcessConfig{
+						"web": {Ports: []*pb.TsuruYamlKubernetesProcessPortConfig{{Name: "http", Protocol: "TCP", Port: 80, TargetPort: 8888}}},
+					},
+				},
+			},
+		},
+	}, got)
+}
@@ -72,5 +72,5 @@ type TsuruYamlKubernetesProcessPortConfig struct {
 	Name       string `json:"name,omitempty"`
 	Protocol   string `json:"protocol,omitempty"`
 	Port       int    `json:"port,omitempty"`
-	TargetPort int    `json:"target_port,omitempty"`
+	TargetPort int    `json:"target_port,omitempty" yaml:"target_port"`
 }
@@ -0,0 +1,275 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	containerregistryname "github.com/google/go-containerregistry/pkg/name"
+	containerregistryv1 "github.com/google/go-containerregistry/pkg/v1"
+	containerregistryempty "github.com/google/go-containerregistry/pkg/v1/empty"
+	containerregistrymutate "github.com/google/go-containerregistry/pkg/v1/mutate"
+	containerregistryremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	containerregistrytransport "github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	containerregistrystatic "github.com/google/go-containerregistry/pkg/v1/static"
+	containerregistrytypes "github.com/google/go-containerregistry/pkg/v1/types"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The constants below follow the signature format of cosign, so signatures
+// pushed by deploy-agent can be verified by cosign (and policy controllers
+// built on top of it).
+//
+// See more: https://github.com/sigstore/cosign/blob/main/specs/SIGNATURE_SPEC.md
+const (
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	SignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	SignatureTagSuffix     = ".sig"
+
+	simpleSigningType = "cosign container image signature"
+)
+
+var encryptedPrivateKeyPEMTypes = []string{"ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY"}
+
+type Signer struct {
+	key crypto.Signer
+}
+
+func NewSigner(key crypto.Signer) (*Signer, error) {
+	switch key.(type) {
+	case *ecdsa.PrivateKey, *rsa.PrivateKey, ed25519.PrivateKey:
+		return &Signer{key: key}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// LoadSigner reads a PEM-encoded private key from filename. Keys generated by
+// "cosign generate-key-pair" are encrypted, so password must be provided.
+func LoadSigner(filename string, password []byte) (*Signer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSigner(data, password)
+}
+
+func ParseSigner(data, password []byte) (*Signer, error) {
+	p, _ := pem.Decode(data)
+	if p == nil {
+		return nil, errors.New("private key must be PEM encoded")
+	}
+
+	var key any
+	var err error
+
+	switch {
+	case isEncryptedPrivateKey(p.Type):
+		var der []byte
+		der, err = decryptPrivateKey(p.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = x509.ParsePKCS8PrivateKey(der)
+
+	case p.Type == "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(p.Bytes)
+
+	case p.Type == "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(p.Bytes)
+
+	case p.Type == "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(p.Bytes)
+
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", p.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	return NewSigner(signer)
+}
+
+func (s *Signer) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+// SignImage signs the container image manifest (or image index) identified by
+// ref and pushes the signature to the same repository, tagged as
+// "<algorithm>-<hex>.sig". Existing signatures in that tag are kept.
+func (s *Signer) SignImage(ctx context.Context, ref containerregistryname.Digest, opts ...containerregistryremote.Option) (containerregistryname.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return containerregistryname.Tag{}, err
+	}
+
+	payload, err := SimpleSigningPayload(ref)
+	if err != nil {
+		return containerregistryname.Tag{}, err
+	}
+
+	sig, err := s.sign(payload)
+	if err != nil {
+		return containerregistryname.Tag{}, fmt.Errorf("failed to sign payload: %w", err)
+	}
+
+	tag := ref.Context().Tag(strings.Replace(ref.DigestStr(), ":", "-", 1) + SignatureTagSuffix)
+
+	opts = append([]containerregistryremote.Option{containerregistryremote.WithContext(ctx)}, opts...)
+
+	base, err := signatureImage(tag, opts...)
+	if err != nil {
+		return containerregistryname.Tag{}, err
+	}
+
+	image, err := containerregistrymutate.Append(base, containerregistrymutate.Addendum{
+		Layer: containerregistrystatic.NewLayer(payload, SimpleSigningMediaType),
+		Annotations: map[string]string{
+			SignatureAnnotation: base64.StdEncoding.EncodeToString(sig),
+		},
+	})
+	if err != nil {
+		return containerregistryname.Tag{}, err
+	}
+
+	if err = containerregistryremote.Write(tag, image, opts...); err != nil {
+		return containerregistryname.Tag{}, fmt.Errorf("failed to push signature: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (s *Signer) sign(payload []byte) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		return s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	}
+
+	digest := sha256.Sum256(payload)
+	return s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+func SimpleSigningPayload(ref containerregistryname.Digest) ([]byte, error) {
+	var p struct {
+		Optional map[string]any `json:"optional"`
+		Critical struct {
+			Identity struct {
+				DockerReference string `json:"docker-reference"`
+			} `json:"identity"`
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+			Type string `json:"type"`
+		} `json:"critical"`
+	}
+
+	p.Critical.Identity.DockerReference = ref.Context().Name()
+	p.Critical.Image.DockerManifestDigest = ref.DigestStr()
+	p.Critical.Type = simpleSigningType
+
+	return json.Marshal(p)
+}
+
+func signatureImage(tag containerregistryname.Tag, opts ...containerregistryremote.Option) (containerregistryv1.Image, error) {
+	image, err := containerregistryremote.Image(tag, opts...)
+	if err == nil {
+		return image, nil
+	}
+
+	var terr *containerregistrytransport.Error
+	if errors.As(err, &terr) && terr.StatusCode == 404 { // no signatures yet
+		image = containerregistrymutate.MediaType(containerregistryempty.Image, containerregistrytypes.OCIManifestSchema1)
+		return containerregistrymutate.ConfigMediaType(image, containerregistrytypes.OCIConfigJSON), nil
+	}
+
+	return nil, fmt.Errorf("failed to get existing signatures: %w", err)
+}
+
+func isEncryptedPrivateKey(pemType string) bool {
+	for _, t := range encryptedPrivateKeyPEMTypes {
+		if t == pemType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decryptPrivateKey decrypts keys in the same format used by cosign, which is
+// a JSON document with scrypt as KDF and NaCl secretbox as cipher.
+func decryptPrivateKey(data, password []byte) ([]byte, error) {
+	var k struct {
+		KDF struct {
+			Name   string `json:"name"`
+			Salt   []byte `json:"salt"`
+			Params struct {
+				N int `json:"N"`
+				R int `json:"r"`
+				P int `json:"p"`
+			} `json:"params"`
+		} `json:"kdf"`
+		Cipher struct {
+			Name  string `json:"name"`
+			Nonce []byte `json:"nonce"`
+		} `json:"cipher"`
+		Ciphertext []byte `json:"ciphertext"`
+	}
+
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted private key: %w", err)
+	}
+
+	if k.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function %q", k.KDF.Name)
+	}
+
+	if k.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported cipher %q", k.Cipher.Name)
+	}
+
+	if len(k.Cipher.Nonce) != 24 {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	dk, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	var key [32]byte
+	copy(key[:], dk)
+
+	var nonce [24]byte
+	copy(nonce[:], k.Cipher.Nonce)
+
+	der, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.New("failed to decrypt private key: invalid password")
+	}
+
+	return der, nil
+}
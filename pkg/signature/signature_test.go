@@ -0,0 +1,174 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package signature_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	containerregistryname "github.com/google/go-containerregistry/pkg/name"
+	containerregistry "github.com/google/go-containerregistry/pkg/registry"
+	containerregistryrandom "github.com/google/go-containerregistry/pkg/v1/random"
+	containerregistryremote "github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	. "github.com/tsuru/deploy-agent/pkg/signature"
+)
+
+func TestSigner_SignImage(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(containerregistry.New(containerregistry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(srv.Close)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := NewSigner(key)
+	require.NoError(t, err)
+
+	image, err := containerregistryrandom.Image(1024, 1)
+	require.NoError(t, err)
+
+	tag, err := containerregistryname.NewTag(strings.TrimPrefix(srv.URL, "http://") + "/tsuru/app-my-app:v1")
+	require.NoError(t, err)
+	require.NoError(t, containerregistryremote.Write(tag, image))
+
+	digest, err := image.Digest()
+	require.NoError(t, err)
+
+	ref := tag.Context().Digest(digest.String())
+
+	for i := 0; i < 2; i++ { // signing twice should keep both signatures
+		sigTag, nerr := signer.SignImage(context.TODO(), ref)
+		require.NoError(t, nerr)
+		assert.Equal(t, tag.Context().Tag(fmt.Sprintf("sha256-%s.sig", digest.Hex)), sigTag)
+	}
+
+	sigImage, err := containerregistryremote.Image(tag.Context().Tag(fmt.Sprintf("sha256-%s.sig", digest.Hex)))
+	require.NoError(t, err)
+
+	m, err := sigImage.Manifest()
+	require.NoError(t, err)
+	assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", string(m.MediaType))
+	require.Len(t, m.Layers, 2)
+
+	layers, err := sigImage.Layers()
+	require.NoError(t, err)
+
+	for i, l := range m.Layers {
+		assert.Equal(t, "application/vnd.dev.cosign.simplesigning.v1+json", string(l.MediaType))
+
+		rc, nerr := layers[i].Uncompressed()
+		require.NoError(t, nerr)
+		payload, nerr := io.ReadAll(rc)
+		require.NoError(t, nerr)
+		rc.Close()
+
+		var p map[string]any
+		require.NoError(t, json.Unmarshal(payload, &p))
+		assert.Equal(t, map[string]any{
+			"critical": map[string]any{
+				"identity": map[string]any{"docker-reference": tag.Context().Name()},
+				"image":    map[string]any{"docker-manifest-digest": digest.String()},
+				"type":     "cosign container image signature",
+			},
+			"optional": nil,
+		}, p)
+
+		sig, nerr := base64.StdEncoding.DecodeString(l.Annotations["dev.cosignproject.cosign/signature"])
+		require.NoError(t, nerr)
+
+		h := sha256.Sum256(payload)
+		assert.True(t, ecdsa.VerifyASN1(&key.PublicKey, h[:], sig), "signature should be valid")
+	}
+}
+
+func TestParseSigner(t *testing.T) {
+	t.Parallel()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	t.Run("plain PKCS#8 private key", func(t *testing.T) {
+		signer, err := ParseSigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil)
+		require.NoError(t, err)
+		assert.Equal(t, key.Public(), signer.Public())
+	})
+
+	t.Run("cosign encrypted private key", func(t *testing.T) {
+		data := encryptPrivateKey(t, der, []byte("s3cr3t"))
+
+		signer, err := ParseSigner(data, []byte("s3cr3t"))
+		require.NoError(t, err)
+		assert.Equal(t, key.Public(), signer.Public())
+
+		_, err = ParseSigner(data, []byte("wrong"))
+		assert.EqualError(t, err, "failed to decrypt private key: invalid password")
+	})
+
+	t.Run("not PEM encoded", func(t *testing.T) {
+		_, err := ParseSigner([]byte("not a key"), nil)
+		assert.EqualError(t, err, "private key must be PEM encoded")
+	})
+
+	t.Run("unsupported PEM block", func(t *testing.T) {
+		_, err := ParseSigner(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("...")}), nil)
+		assert.EqualError(t, err, `unsupported PEM block type "CERTIFICATE"`)
+	})
+}
+
+func encryptPrivateKey(t *testing.T, der, password []byte) []byte {
+	t.Helper()
+
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+
+	var nonce [24]byte
+	_, err = rand.Read(nonce[:])
+	require.NoError(t, err)
+
+	dk, err := scrypt.Key(password, salt, 1<<10, 8, 1, 32)
+	require.NoError(t, err)
+
+	var key [32]byte
+	copy(key[:], dk)
+
+	data, err := json.Marshal(map[string]any{
+		"kdf": map[string]any{
+			"name":   "scrypt",
+			"params": map[string]int{"N": 1 << 10, "r": 8, "p": 1},
+			"salt":   salt,
+		},
+		"cipher": map[string]any{
+			"name":  "nacl/secretbox",
+			"nonce": nonce[:],
+		},
+		"ciphertext": secretbox.Seal(nil, der, &nonce, &key),
+	})
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: data})
+}
@@ -0,0 +1,688 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/moby/patternmatcher"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ArchiveFormat int
+
+const (
+	ArchiveFormatUnknown ArchiveFormat = iota
+	ArchiveFormatTar
+	ArchiveFormatTarGZIP
+	ArchiveFormatTarZstd
+	ArchiveFormatZip
+)
+
+func (f ArchiveFormat) String() string {
+	switch f {
+	case ArchiveFormatTar:
+		return "tar"
+	case ArchiveFormatTarGZIP:
+		return "tar.gz"
+	case ArchiveFormatTarZstd:
+		return "tar.zst"
+	case ArchiveFormatZip:
+		return "zip"
+	}
+
+	return "unknown"
+}
+
+var (
+	gzipMagic      = []byte{0x1f, 0x8b}
+	zstdMagic      = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic       = []byte("PK\x03\x04")
+	zipEmptyMagic  = []byte("PK\x05\x06")
+	tarMagic       = []byte("ustar")
+	tarMagicOffset = 257
+)
+
+const unknownFormatMessage = "archive must be either tar, tar.gz, tar.zst or zip"
+
+// DetectArchiveFormat detects the archive format from the magic bytes of data.
+func DetectArchiveFormat(data []byte) ArchiveFormat {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return ArchiveFormatTarGZIP
+
+	case bytes.HasPrefix(data, zstdMagic):
+		return ArchiveFormatTarZstd
+
+	case bytes.HasPrefix(data, zipMagic), bytes.HasPrefix(data, zipEmptyMagic):
+		return ArchiveFormatZip
+
+	case len(data) >= tarMagicOffset+len(tarMagic) && bytes.Equal(data[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic):
+		return ArchiveFormatTar
+	}
+
+	return ArchiveFormatUnknown
+}
+
+// Archive reads tar (optionally compressed with GZIP or Zstandard) and zip
+// archives through the same API, where entries are described as tar headers.
+type Archive struct {
+	data    []byte
+	format  ArchiveFormat
+	exclude *patternmatcher.PatternMatcher
+	limits  ExtractOptions
+}
+
+func NewArchive(data []byte) (*Archive, error) {
+	format := DetectArchiveFormat(data)
+	if format == ArchiveFormatUnknown {
+		return nil, &InvalidArchiveError{Message: unknownFormatMessage}
+	}
+
+	return &Archive{data: data, format: format, limits: ExtractOptions{}.withDefaults()}, nil
+}
+
+// WithLimits returns a view of the archive whose walks (and so conversions
+// and extractions) fail once they exceed the limits of opts.
+func (a *Archive) WithLimits(opts ExtractOptions) *Archive {
+	limited := *a
+	limited.limits = opts.withDefaults()
+	return &limited
+}
+
+func (a *Archive) Format() ArchiveFormat {
+	return a.format
+}
+
+// Size returns the archive size in bytes (compressed, if so).
+func (a *Archive) Size() int64 {
+	return int64(len(a.data))
+}
+
+// WalkFunc is called for every archive entry. The reader holds the file
+// content, if any, and is only valid until WalkFunc returns.
+type WalkFunc func(h *tar.Header, r io.Reader) error
+
+// Walk calls fn for each entry in the same order they're stored, except for
+// the excluded ones (see ExcludeIgnoredFiles). It fails once the entries
+// exceed the archive limits (see WithLimits), excluded ones included.
+func (a *Archive) Walk(ctx context.Context, fn WalkFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if a.exclude != nil {
+		walk := fn
+		fn = func(h *tar.Header, r io.Reader) error {
+			excluded, err := a.excluded(h)
+			if err != nil || excluded {
+				return err
+			}
+
+			return walk(h, r)
+		}
+	}
+
+	fn = a.limited(fn)
+
+	switch a.format {
+	case ArchiveFormatTar:
+		return walkTar(ctx, bytes.NewReader(a.data), fn)
+
+	case ArchiveFormatTarGZIP:
+		zr, err := gzip.NewReader(bytes.NewReader(a.data))
+		if err != nil {
+			return invalidArchive("failed to decompress GZIP: %s", err)
+		}
+		defer zr.Close()
+
+		return walkTar(ctx, zr, fn)
+
+	case ArchiveFormatTarZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(a.data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return invalidArchive("failed to decompress Zstandard: %s", err)
+		}
+		defer zr.Close()
+
+		return walkTar(ctx, zr, fn)
+
+	case ArchiveFormatZip:
+		return walkZip(ctx, a.data, fn)
+	}
+
+	return &InvalidArchiveError{Message: unknownFormatMessage}
+}
+
+// Files returns the paths (relative to the archive root and sorted) of every
+// entry but dirs, except for the excluded ones.
+func (a *Archive) Files(ctx context.Context) ([]string, error) {
+	var files []string
+	err := a.Walk(ctx, func(h *tar.Header, _ io.Reader) error {
+		if h.Typeflag == tar.TypeDir {
+			return nil
+		}
+
+		if name := archivePath(h.Name); name != "" {
+			files = append(files, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// WriteTarGZIP converts the archive to a tarball compressed with GZIP. In
+// reproducible mode, entries are normalized and sorted by name as in
+// CompressGZIPFile, so the same files always give the same tarball regardless
+// of their order in the archive.
+func (a *Archive) WriteTarGZIP(ctx context.Context, w io.Writer, opts CompressOptions) error {
+	if a.format == ArchiveFormatTarGZIP && a.exclude == nil && !opts.Reproducible {
+		_, err := w.Write(a.data)
+		return err
+	}
+
+	var modTime time.Time
+	if opts.Reproducible {
+		var err error
+		if modTime, err = opts.modTime(); err != nil {
+			return err
+		}
+	}
+
+	zw, err := newGZIPWriter(w)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(zw)
+
+	walk := a.Walk
+	if opts.Reproducible {
+		walk = func(ctx context.Context, fn WalkFunc) error { return a.walkSorted(ctx, opts.TempDir, fn) }
+	}
+
+	err = walk(ctx, func(h *tar.Header, r io.Reader) error {
+		if opts.Reproducible {
+			normalizeTarHeader(h, modTime)
+		}
+
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+
+		if h.Typeflag != tar.TypeReg {
+			return nil
+		}
+
+		_, err := io.CopyN(tw, r, h.Size)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// walkSorted is like Walk, but entries are sorted by name, except for hard
+// links which are kept after their targets. Contents of regular files are
+// spooled to a temporary file in tempDir meanwhile, so memory usage doesn't
+// grow with the archive size.
+func (a *Archive) walkSorted(ctx context.Context, tempDir string, fn WalkFunc) error {
+	spool, err := os.CreateTemp(tempDir, "deploy-agent-archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var entries []spooledEntry
+	var offset int64
+
+	err = a.Walk(ctx, func(h *tar.Header, r io.Reader) error {
+		entries = append(entries, spooledEntry{header: h, offset: offset})
+
+		if h.Typeflag != tar.TypeReg {
+			return nil
+		}
+
+		n, err := io.CopyN(spool, r, h.Size)
+		offset += n
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range sortEntries(entries) {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		if err = fn(e.header, io.NewSectionReader(spool, e.offset, e.header.Size)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type spooledEntry struct {
+	header *tar.Header
+	offset int64
+}
+
+// sortEntries sorts entries by name, moving each hard link right after its
+// target when it'd come first, since extracting a hard link requires the
+// target to exist. Hard links to missing targets are left last.
+func sortEntries(entries []spooledEntry) []spooledEntry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].header.Name < entries[j].header.Name })
+
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[archivePath(e.header.Name)] = true
+	}
+
+	sorted := make([]spooledEntry, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	pending := make(map[string][]spooledEntry) // hard links by target
+
+	var add func(e spooledEntry)
+	add = func(e spooledEntry) {
+		name := archivePath(e.header.Name)
+
+		sorted = append(sorted, e)
+		seen[name] = true
+
+		links := pending[name]
+		delete(pending, name)
+
+		for _, l := range links {
+			add(l)
+		}
+	}
+
+	var orphans []spooledEntry
+
+	for _, e := range entries {
+		target := archivePath(e.header.Linkname)
+
+		switch {
+		case e.header.Typeflag != tar.TypeLink, seen[target]:
+			add(e)
+
+		case names[target] && target != archivePath(e.header.Name):
+			pending[target] = append(pending[target], e)
+
+		default:
+			orphans = append(orphans, e)
+		}
+	}
+
+	// NOTE: only hard links in cycles (e.g. a -> b -> a) are still pending.
+	for _, e := range entries {
+		name := archivePath(e.header.Name)
+		if links, found := pending[name]; found {
+			delete(pending, name)
+			orphans = append(orphans, links...)
+		}
+	}
+
+	return append(sorted, orphans...)
+}
+
+func walkTar(ctx context.Context, r io.Reader, fn WalkFunc) error {
+	tr := tar.NewReader(r)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return invalidArchive("failed to read next file: %s", err)
+		}
+
+		if err = fn(h, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(ctx context.Context, data []byte, fn WalkFunc) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return invalidArchive("failed to read zip: %s", err)
+	}
+
+	for _, f := range zr.File {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		if err = walkZipFile(f, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkZipFile(f *zip.File, fn WalkFunc) error {
+	mode := f.Mode()
+
+	h := &tar.Header{
+		Name:    f.Name,
+		Mode:    int64(mode.Perm()),
+		ModTime: f.Modified,
+		Uid:     os.Getuid(), // zip doesn't keep the owner of files
+		Gid:     os.Getgid(),
+	}
+
+	if mode.IsDir() || strings.HasSuffix(f.Name, "/") {
+		h.Typeflag = tar.TypeDir
+		return fn(h, bytes.NewReader(nil))
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return invalidArchive("failed to open %q: %s", f.Name, err)
+	}
+	defer rc.Close()
+
+	if mode&fs.ModeSymlink != 0 {
+		link, err := io.ReadAll(io.LimitReader(rc, 4096))
+		if err != nil {
+			return invalidArchive("failed to read %q: %s", f.Name, err)
+		}
+
+		h.Typeflag, h.Linkname = tar.TypeSymlink, string(link)
+		return fn(h, bytes.NewReader(nil))
+	}
+
+	if !mode.IsRegular() {
+		return invalidArchive("%q has unsupported file type %s", f.Name, mode.Type())
+	}
+
+	h.Typeflag, h.Size = tar.TypeReg, int64(f.UncompressedSize64)
+	return fn(h, rc)
+}
+
+const (
+	DefaultExtractMaxSize             = 4 * (1 << 30) // 4 GiB
+	DefaultExtractMaxFiles            = 100_000
+	DefaultExtractMaxCompressionRatio = 100
+
+	// compression ratio is only checked after this amount of uncompressed
+	// data, since small (and legit) files can be highly compressed.
+	minUncompressedSizeToCheckRatio = 1 << 20 // 1 MiB
+)
+
+type ExtractOptions struct {
+	// MaxSize is the max size in bytes of all files (uncompressed).
+	// Defaults to DefaultExtractMaxSize.
+	MaxSize int64
+	// MaxFiles is the max number of entries (files, dirs, links).
+	// Defaults to DefaultExtractMaxFiles.
+	MaxFiles int
+	// MaxCompressionRatio is the max ratio between uncompressed and compressed sizes.
+	// Defaults to DefaultExtractMaxCompressionRatio.
+	MaxCompressionRatio int64
+}
+
+func (o ExtractOptions) withDefaults() ExtractOptions {
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultExtractMaxSize
+	}
+
+	if o.MaxFiles <= 0 {
+		o.MaxFiles = DefaultExtractMaxFiles
+	}
+
+	if o.MaxCompressionRatio <= 0 {
+		o.MaxCompressionRatio = DefaultExtractMaxCompressionRatio
+	}
+
+	return o
+}
+
+// limited wraps fn checking the number of entries, their total size and the
+// compression ratio against the archive limits.
+func (a *Archive) limited(fn WalkFunc) WalkFunc {
+	opts := a.limits.withDefaults()
+
+	var size int64
+	var files int
+
+	return func(h *tar.Header, r io.Reader) error {
+		if files++; files > opts.MaxFiles {
+			return invalidArchive("number of files exceeds the limit of %d", opts.MaxFiles)
+		}
+
+		if size += h.Size; size > opts.MaxSize {
+			return invalidArchive("size of files exceeds the limit of %d bytes", opts.MaxSize)
+		}
+
+		if size > minUncompressedSizeToCheckRatio && size > a.Size()*opts.MaxCompressionRatio {
+			return invalidArchive("compression ratio exceeds the limit of %d", opts.MaxCompressionRatio)
+		}
+
+		return fn(h, r)
+	}
+}
+
+// InvalidArchiveError is returned when the archive is malformed or violates
+// the extraction limits. It's reported as codes.InvalidArgument to gRPC clients.
+type InvalidArchiveError struct {
+	Message string
+}
+
+func (e *InvalidArchiveError) Error() string {
+	return "invalid archive: " + e.Message
+}
+
+func (e *InvalidArchiveError) GRPCStatus() *status.Status {
+	return status.New(codes.InvalidArgument, e.Error())
+}
+
+func invalidArchive(format string, args ...any) error {
+	return &InvalidArchiveError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ExtractToDir extracts the archive into dst dir, which must exist. Entries
+// cannot be written outside of dst, neither directly (e.g. ../../etc/passwd)
+// nor through symbolic links.
+func (a *Archive) ExtractToDir(ctx context.Context, dst string, opts ExtractOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(dst)
+	if err != nil {
+		return err
+	}
+
+	return a.WithLimits(opts).Walk(ctx, func(h *tar.Header, r io.Reader) error {
+		target, err := secureJoin(dir, h.Name)
+		if err != nil {
+			return err
+		}
+
+		return extractTarEntry(dir, target, h, r)
+	})
+}
+
+func extractTarEntry(dir, target string, h *tar.Header, r io.Reader) error {
+	finfo := h.FileInfo()
+
+	if target == dir { // root dir, e.g. "./"
+		if h.Typeflag == tar.TypeDir {
+			return nil
+		}
+
+		return invalidArchive("%q cannot override the root dir", h.Name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	if h.Typeflag != tar.TypeDir { // avoids writing through a symlink previously extracted
+		if fi, err := os.Lstat(target); err == nil {
+			if fi.IsDir() {
+				return invalidArchive("%q already exists as a dir", h.Name)
+			}
+
+			if err = os.Remove(target); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch h.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, finfo.Mode()); err != nil {
+			return err
+		}
+
+	case tar.TypeReg:
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, finfo.Mode())
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.CopyN(f, r, finfo.Size()); err != nil {
+			f.Close()
+			return invalidArchive("failed to read %q: %s", h.Name, err)
+		}
+
+		if err = f.Chown(h.Uid, h.Gid); err != nil {
+			f.Close()
+			return err
+		}
+
+		if err = f.Close(); err != nil {
+			return err
+		}
+
+		if err = os.Chtimes(target, h.ChangeTime, finfo.ModTime()); err != nil {
+			return err
+		}
+
+	case tar.TypeSymlink:
+		if filepath.IsAbs(h.Linkname) {
+			return invalidArchive("symbolic link %q cannot point to absolute path %q", h.Name, h.Linkname)
+		}
+
+		if _, err := secureJoin(dir, filepath.Join(relativeTo(dir, filepath.Dir(target)), h.Linkname)); err != nil {
+			return invalidArchive("symbolic link %q points outside of destination dir", h.Name)
+		}
+
+		if err := os.Symlink(h.Linkname, target); err != nil {
+			return err
+		}
+
+		if err := os.Lchown(target, h.Uid, h.Gid); err != nil {
+			return err
+		}
+
+	case tar.TypeLink:
+		source, err := secureJoin(dir, h.Linkname)
+		if err != nil {
+			return err
+		}
+
+		fi, err := os.Lstat(source)
+		if err != nil || !fi.Mode().IsRegular() {
+			return invalidArchive("hard link %q must point to a regular file previously extracted", h.Name)
+		}
+
+		if err = os.Link(source, target); err != nil {
+			return err
+		}
+
+	default:
+		return invalidArchive("not supported file type at file %q", h.Name)
+	}
+
+	return nil
+}
+
+// secureJoin joins name onto dir ensuring the result stays inside of dir,
+// even when following symbolic links already extracted.
+func secureJoin(dir, name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", invalidArchive("%q is outside of destination dir", name)
+	}
+
+	target := filepath.Join(dir, cleaned)
+
+	// every parent component must be a real dir (not a symlink), otherwise
+	// the file could be written anywhere.
+	current := dir
+	parts := strings.Split(cleaned, string(filepath.Separator))
+	for _, p := range parts[:len(parts)-1] {
+		if p == "." {
+			continue
+		}
+
+		current = filepath.Join(current, p)
+
+		fi, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return "", invalidArchive("%q is located through the symbolic link %q", name, relativeTo(dir, current))
+		}
+	}
+
+	return target, nil
+}
+
+func relativeTo(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+
+	return rel
+}
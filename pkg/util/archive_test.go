@@ -0,0 +1,364 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/tsuru/deploy-agent/pkg/util"
+)
+
+var archiveEntries = []tarEntry{
+	{name: "app/", typeflag: tar.TypeDir},
+	{name: "app/main.py", typeflag: tar.TypeReg, data: "print('hello world')"},
+	{name: "app/settings.ini", typeflag: tar.TypeSymlink, linkname: "../settings.ini"},
+	{name: "settings.ini", typeflag: tar.TypeReg, data: "debug = true"},
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		data     []byte
+		expected ArchiveFormat
+	}{
+		"empty":   {expected: ArchiveFormatUnknown},
+		"text":    {data: []byte("not an archive"), expected: ArchiveFormatUnknown},
+		"tar":     {data: newTar(t, archiveEntries), expected: ArchiveFormatTar},
+		"tar.gz":  {data: newTarGZ(t, archiveEntries).Bytes(), expected: ArchiveFormatTarGZIP},
+		"tar.zst": {data: newTarZstd(t, archiveEntries), expected: ArchiveFormatTarZstd},
+		"zip":     {data: newZip(t, archiveEntries), expected: ArchiveFormatZip},
+		"empty zip": {
+			data:     newZip(t, nil),
+			expected: ArchiveFormatZip,
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, DetectArchiveFormat(tt.data))
+		})
+	}
+}
+
+func TestNewArchive(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewArchive([]byte("not an archive"))
+	require.EqualError(t, err, "invalid archive: archive must be either tar, tar.gz, tar.zst or zip")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestArchive_ExtractToDir(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string][]byte{
+		"tar":     newTar(t, archiveEntries),
+		"tar.gz":  newTarGZ(t, archiveEntries).Bytes(),
+		"tar.zst": newTarZstd(t, archiveEntries),
+		"zip":     newZip(t, archiveEntries),
+	}
+
+	for name, data := range cases {
+		name, data := name, data
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewArchive(data)
+			require.NoError(t, err)
+			assert.Equal(t, name, a.Format().String())
+
+			dir := t.TempDir()
+			require.NoError(t, a.ExtractToDir(context.TODO(), dir, ExtractOptions{}))
+
+			got, err := os.ReadFile(filepath.Join(dir, "app", "main.py"))
+			require.NoError(t, err)
+			assert.Equal(t, "print('hello world')", string(got))
+
+			got, err = os.ReadFile(filepath.Join(dir, "app", "settings.ini"))
+			require.NoError(t, err)
+			assert.Equal(t, "debug = true", string(got))
+		})
+	}
+
+	t.Run("zip with path traversal", func(t *testing.T) {
+		a, err := NewArchive(newZip(t, []tarEntry{{name: "../../etc/passwd", typeflag: tar.TypeReg}}))
+		require.NoError(t, err)
+
+		err = a.ExtractToDir(context.TODO(), t.TempDir(), ExtractOptions{})
+		require.EqualError(t, err, `invalid archive: "../../etc/passwd" is outside of destination dir`)
+	})
+}
+
+func TestArchive_Files(t *testing.T) {
+	t.Parallel()
+
+	entries := append([]tarEntry{{name: "./", typeflag: tar.TypeDir}, {name: "./.tsuruignore", typeflag: tar.TypeReg, data: "**/*.ini"}}, archiveEntries...)
+
+	for _, data := range [][]byte{newTarGZ(t, entries).Bytes(), newZip(t, entries[1:])} {
+		a, err := NewArchive(data)
+		require.NoError(t, err)
+
+		files, err := a.Files(context.TODO())
+		require.NoError(t, err)
+		assert.Equal(t, []string{".tsuruignore", "app/main.py", "app/settings.ini", "settings.ini"}, files)
+
+		filtered, _, err := ExcludeIgnoredFiles(context.TODO(), a, ".tsuruignore")
+		require.NoError(t, err)
+
+		files, err = filtered.Files(context.TODO())
+		require.NoError(t, err)
+		assert.Equal(t, []string{".tsuruignore", "app/main.py"}, files)
+	}
+}
+
+func TestArchive_WriteTarGZIP(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range [][]byte{newTar(t, archiveEntries), newTarZstd(t, archiveEntries), newZip(t, archiveEntries)} {
+		a, err := NewArchive(data)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		require.NoError(t, a.WriteTarGZIP(context.TODO(), &b, CompressOptions{}))
+
+		converted, err := NewArchive(b.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, ArchiveFormatTarGZIP, converted.Format())
+
+		var got []tarEntry
+		err = converted.Walk(context.TODO(), func(h *tar.Header, r io.Reader) error {
+			content, nerr := io.ReadAll(r)
+			got = append(got, tarEntry{name: h.Name, typeflag: h.Typeflag, linkname: h.Linkname, data: string(content)})
+			return nerr
+		})
+		require.NoError(t, err)
+		assert.Equal(t, archiveEntries, got)
+	}
+}
+
+func TestArchive_WriteTarGZIP_Reproducible(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewArchive(newTarGZ(t, archiveEntries).Bytes())
+	require.NoError(t, err)
+
+	opts := CompressOptions{Reproducible: true, ModTime: time.Unix(1700000000, 0)}
+
+	var first, second bytes.Buffer
+	require.NoError(t, a.WriteTarGZIP(context.TODO(), &first, opts))
+	require.NoError(t, a.WriteTarGZIP(context.TODO(), &second, opts))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+
+	converted, err := NewArchive(first.Bytes())
+	require.NoError(t, err)
+
+	err = converted.Walk(context.TODO(), func(h *tar.Header, _ io.Reader) error {
+		assert.Equal(t, 0, h.Uid)
+		assert.Equal(t, 0, h.Gid)
+		assert.Equal(t, time.Unix(1700000000, 0), h.ModTime)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestArchive_WriteTarGZIP_ReproducibleOrder(t *testing.T) {
+	t.Parallel()
+
+	reversed := make([]tarEntry, 0, len(archiveEntries))
+	for i := len(archiveEntries) - 1; i >= 0; i-- {
+		reversed = append(reversed, archiveEntries[i])
+	}
+
+	opts := CompressOptions{Reproducible: true, ModTime: time.Unix(1700000000, 0)}
+
+	var outputs [][]byte
+	for _, data := range [][]byte{newTarGZ(t, archiveEntries).Bytes(), newTarGZ(t, reversed).Bytes(), newTarZstd(t, reversed)} {
+		a, err := NewArchive(data)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		require.NoError(t, a.WriteTarGZIP(context.TODO(), &b, opts))
+		outputs = append(outputs, b.Bytes())
+	}
+
+	assert.Equal(t, outputs[0], outputs[1])
+	assert.Equal(t, outputs[0], outputs[2])
+
+	converted, err := NewArchive(outputs[1])
+	require.NoError(t, err)
+
+	var got []tarEntry
+	err = converted.Walk(context.TODO(), func(h *tar.Header, r io.Reader) error {
+		content, nerr := io.ReadAll(r)
+		got = append(got, tarEntry{name: h.Name, typeflag: h.Typeflag, linkname: h.Linkname, data: string(content)})
+		return nerr
+	})
+	require.NoError(t, err)
+	assert.Equal(t, archiveEntries, got)
+}
+
+func TestArchive_WriteTarGZIP_ReproducibleHardLinks(t *testing.T) {
+	t.Parallel()
+
+	a, err := NewArchive(newTarGZ(t, []tarEntry{
+		{name: "z-target.txt", typeflag: tar.TypeReg, data: "hello"},
+		{name: "b-link.txt", typeflag: tar.TypeLink, linkname: "z-target.txt"},
+		{name: "a-link.txt", typeflag: tar.TypeLink, linkname: "b-link.txt"},
+		{name: "c-file.txt", typeflag: tar.TypeReg, data: "world"},
+		{name: "d-dangling.txt", typeflag: tar.TypeLink, linkname: "missing.txt"},
+	}).Bytes())
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+
+	var b bytes.Buffer
+	require.NoError(t, a.WriteTarGZIP(context.TODO(), &b, CompressOptions{Reproducible: true, TempDir: tempDir}))
+
+	spooled, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, spooled)
+
+	converted, err := NewArchive(b.Bytes())
+	require.NoError(t, err)
+
+	var names []string
+	err = converted.Walk(context.TODO(), func(h *tar.Header, _ io.Reader) error {
+		names = append(names, h.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c-file.txt", "z-target.txt", "b-link.txt", "a-link.txt", "d-dangling.txt"}, names)
+
+	err = a.WriteTarGZIP(context.TODO(), io.Discard, CompressOptions{Reproducible: true, TempDir: filepath.Join(tempDir, "not-found")})
+	assert.Error(t, err)
+}
+
+func TestArchive_WriteTarGZIP_Limits(t *testing.T) {
+	t.Parallel()
+
+	bomb := []tarEntry{{name: "zeros", typeflag: tar.TypeReg, data: strings.Repeat("\x00", 4<<20)}}
+
+	tests := map[string]struct {
+		data     []byte
+		opts     ExtractOptions
+		expected string
+	}{
+		"zstd bomb": {
+			data:     newTarZstd(t, bomb),
+			expected: "invalid archive: compression ratio exceeds the limit of 100",
+		},
+		"zip bomb": {
+			data:     newZip(t, bomb),
+			expected: "invalid archive: compression ratio exceeds the limit of 100",
+		},
+		"too many files": {
+			data:     newZip(t, archiveEntries),
+			opts:     ExtractOptions{MaxFiles: 2},
+			expected: "invalid archive: number of files exceeds the limit of 2",
+		},
+		"too large": {
+			data:     newTarZstd(t, archiveEntries),
+			opts:     ExtractOptions{MaxSize: 16},
+			expected: "invalid archive: size of files exceeds the limit of 16 bytes",
+		},
+		"zip with named pipe": {
+			data:     newZip(t, []tarEntry{{name: "fifo", typeflag: tar.TypeFifo}}),
+			expected: `invalid archive: "fifo" has unsupported file type p---------`,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewArchive(tt.data)
+			require.NoError(t, err)
+
+			err = a.WithLimits(tt.opts).WriteTarGZIP(context.TODO(), io.Discard, CompressOptions{})
+			require.EqualError(t, err, tt.expected)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func newTar(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	z, err := gzip.NewReader(newTarGZ(t, entries))
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(z)
+	require.NoError(t, err)
+
+	return data
+}
+
+func newTarZstd(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	zw, err := zstd.NewWriter(&b)
+	require.NoError(t, err)
+
+	_, err = zw.Write(newTar(t, entries))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	return b.Bytes()
+}
+
+func newZip(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+
+		switch e.typeflag {
+		case tar.TypeDir:
+			h.SetMode(fs.ModeDir | 0755)
+		case tar.TypeSymlink:
+			h.SetMode(fs.ModeSymlink | 0777)
+			e.data = e.linkname
+		case tar.TypeFifo:
+			h.SetMode(fs.ModeNamedPipe | 0644)
+		default:
+			h.SetMode(0644)
+		}
+
+		f, err := zw.CreateHeader(h)
+		require.NoError(t, err)
+
+		_, err = io.WriteString(f, e.data)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return b.Bytes()
+}
@@ -8,20 +8,100 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 )
 
-func CompressGZIPFile(ctx context.Context, w io.Writer, rootDir string) error {
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+type CompressOptions struct {
+	// Reproducible makes the same content always give the same tarball:
+	// entries are sorted by name, owned by root (uid/gid 0, no user/group
+	// names), have ModTime as mtime and the GZIP header has no mtime nor name.
+	Reproducible bool
+	// ModTime is the mtime of every entry in reproducible mode. Defaults to
+	// SOURCE_DATE_EPOCH env var, if set, otherwise Unix epoch.
+	ModTime time.Time
+	// TempDir is where temporary files are created, e.g. the file contents
+	// spooled while sorting archive entries. Defaults to os.TempDir.
+	TempDir string
+}
+
+// SourceDateEpoch parses the SOURCE_DATE_EPOCH env var, the number of
+// seconds since Unix epoch. It returns false if the env var is not set.
+//
+// See more: https://reproducible-builds.org/docs/source-date-epoch/
+func SourceDateEpoch() (time.Time, bool, error) {
+	value, found := os.LookupEnv(sourceDateEpochEnv)
+	if !found || value == "" {
+		return time.Time{}, false, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false, fmt.Errorf("%s must be a non-negative integer: %q", sourceDateEpochEnv, value)
+	}
+
+	return time.Unix(seconds, 0).UTC(), true, nil
+}
+
+func (o CompressOptions) modTime() (time.Time, error) {
+	if !o.ModTime.IsZero() {
+		return o.ModTime.UTC().Truncate(time.Second), nil
+	}
+
+	epoch, found, err := SourceDateEpoch()
+	if err != nil || found {
+		return epoch, err
+	}
+
+	return time.Unix(0, 0).UTC(), nil
+}
+
+// normalizeTarHeader drops from h every attribute that depends on who,
+// where and when the archive was made.
+func normalizeTarHeader(h *tar.Header, modTime time.Time) {
+	h.Uid, h.Gid = 0, 0
+	h.Uname, h.Gname = "", ""
+	h.ModTime = modTime
+	h.AccessTime, h.ChangeTime = time.Time{}, time.Time{}
+	h.PAXRecords = nil
+	h.Format = tar.FormatUnknown
+}
+
+// newGZIPWriter returns a writer whose GZIP header holds neither mtime nor
+// file name, so it doesn't change across runs.
+func newGZIPWriter(w io.Writer) (*gzip.Writer, error) {
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	zw.Header = gzip.Header{OS: 255} // unknown OS, see RFC 1952
+	return zw, nil
+}
+
+// CompressGZIPFile writes the files in rootDir as a tarball (GZIP compressed).
+// Entries are written in lexical order since dirs are walked so.
+func CompressGZIPFile(ctx context.Context, w io.Writer, rootDir string, opts CompressOptions) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	var modTime time.Time
+	if opts.Reproducible {
+		var err error
+		if modTime, err = opts.modTime(); err != nil {
+			return err
+		}
+	}
+
+	zw, err := newGZIPWriter(w)
 	if err != nil {
 		return err
 	}
@@ -77,11 +157,15 @@ func CompressGZIPFile(ctx context.Context, w io.Writer, rootDir string) error {
 			return err
 		}
 
-		h.Name = relpath
+		h.Name = filepath.ToSlash(relpath)
 		if d.IsDir() {
 			h.Name += "/"
 		}
 
+		if opts.Reproducible {
+			normalizeTarHeader(h, modTime)
+		}
+
 		if err = tw.WriteHeader(h); err != nil {
 			return err
 		}
@@ -107,79 +191,22 @@ func CompressGZIPFile(ctx context.Context, w io.Writer, rootDir string) error {
 	}))
 }
 
-func ExtractGZIPFileToDir(ctx context.Context, r io.Reader, dst string) error {
+// ExtractGZIPFileToDir extracts the tarball (GZIP compressed) into dst dir.
+// See Archive.ExtractToDir for more.
+func ExtractGZIPFileToDir(ctx context.Context, r io.Reader, dst string, opts ExtractOptions) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	dir, err := filepath.Abs(dst)
-	if err != nil {
-		return err
-	}
-
-	zr, err := gzip.NewReader(r)
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
-	tr := tar.NewReader(zr)
-
-	for {
-		if err := ctx.Err(); err != nil {
-			return err
-		}
-
-		h, err := tr.Next()
-		if errors.Is(err, io.EOF) {
-			break
-		}
-
-		finfo := h.FileInfo()
-
-		target := filepath.Join(dir, filepath.Clean(h.Name))
-
-		switch h.Typeflag {
-		case tar.TypeDir:
-			if err = os.MkdirAll(target, finfo.Mode()); err != nil {
-				return err
-			}
-
-		case tar.TypeReg:
-			var f *os.File
-			f, err = os.OpenFile(target, os.O_CREATE|os.O_RDWR, finfo.Mode())
-			if err != nil {
-				return err
-			}
-
-			if _, err = io.CopyN(f, tr, finfo.Size()); err != nil {
-				return err
-			}
-
-			if err = f.Chown(h.Uid, h.Gid); err != nil {
-				return err
-			}
-
-			if err = f.Close(); err != nil {
-				return err
-			}
-
-			if err = os.Chtimes(target, h.ChangeTime, finfo.ModTime()); err != nil {
-				return err
-			}
-
-		case tar.TypeSymlink:
-			if err = os.Symlink(h.Linkname, target); err != nil {
-				return err
-			}
-
-			if err = os.Lchown(target, h.Uid, h.Gid); err != nil {
-				return err
-			}
-
-		default:
-			return fmt.Errorf("not supported file type at file %q", h.Name)
-		}
+	a, err := NewArchive(data)
+	if err != nil || a.Format() != ArchiveFormatTarGZIP {
+		return invalidArchive("not a GZIP compressed file")
 	}
 
-	return nil
+	return a.ExtractToDir(ctx, dst, opts)
 }
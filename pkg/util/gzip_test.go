@@ -0,0 +1,279 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	. "github.com/tsuru/deploy-agent/pkg/util"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	data     string
+}
+
+func TestExtractGZIPFileToDir(t *testing.T) {
+	t.Parallel()
+
+	t.Run("extracts dirs, files and links", func(t *testing.T) {
+		dir := t.TempDir()
+
+		err := ExtractGZIPFileToDir(context.TODO(), newTarGZ(t, []tarEntry{
+			{name: "./", typeflag: tar.TypeDir},
+			{name: "app/", typeflag: tar.TypeDir},
+			{name: "app/main.py", typeflag: tar.TypeReg, data: "print('hello world')"},
+			{name: "config/settings.ini", typeflag: tar.TypeReg, data: "debug = true"}, // parent dir not in the archive
+			{name: "app/settings.ini", typeflag: tar.TypeSymlink, linkname: "../config/settings.ini"},
+			{name: "app/main-copy.py", typeflag: tar.TypeLink, linkname: "app/main.py"},
+		}), dir, ExtractOptions{})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "app", "main.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print('hello world')", string(data))
+
+		data, err = os.ReadFile(filepath.Join(dir, "app", "settings.ini"))
+		require.NoError(t, err)
+		assert.Equal(t, "debug = true", string(data))
+
+		data, err = os.ReadFile(filepath.Join(dir, "app", "main-copy.py"))
+		require.NoError(t, err)
+		assert.Equal(t, "print('hello world')", string(data))
+	})
+
+	t.Run("replaces symlink instead of writing through it", func(t *testing.T) {
+		dir := t.TempDir()
+
+		err := ExtractGZIPFileToDir(context.TODO(), newTarGZ(t, []tarEntry{
+			{name: "target.txt", typeflag: tar.TypeReg, data: "original"},
+			{name: "link.txt", typeflag: tar.TypeSymlink, linkname: "target.txt"},
+			{name: "link.txt", typeflag: tar.TypeReg, data: "overridden"},
+		}), dir, ExtractOptions{})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "target.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(data))
+
+		data, err = os.ReadFile(filepath.Join(dir, "link.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "overridden", string(data))
+	})
+
+	cases := map[string]struct {
+		entries       []tarEntry
+		data          []byte
+		opts          ExtractOptions
+		expectedError string
+	}{
+		"not gzip": {
+			data:          []byte("not gzip"),
+			expectedError: "invalid archive: not a GZIP compressed file",
+		},
+
+		"path traversal": {
+			entries:       []tarEntry{{name: "../../etc/cron.d/evil", typeflag: tar.TypeReg, data: "* * * * * root /bin/sh"}},
+			expectedError: `invalid archive: "../../etc/cron.d/evil" is outside of destination dir`,
+		},
+
+		"absolute path": {
+			entries:       []tarEntry{{name: "/etc/passwd", typeflag: tar.TypeReg}},
+			expectedError: `invalid archive: "/etc/passwd" is outside of destination dir`,
+		},
+
+		"symlink to absolute path": {
+			entries:       []tarEntry{{name: "etc", typeflag: tar.TypeSymlink, linkname: "/etc"}},
+			expectedError: `invalid archive: symbolic link "etc" cannot point to absolute path "/etc"`,
+		},
+
+		"symlink escaping destination dir": {
+			entries:       []tarEntry{{name: "app/etc", typeflag: tar.TypeSymlink, linkname: "../../etc"}},
+			expectedError: `invalid archive: symbolic link "app/etc" points outside of destination dir`,
+		},
+
+		"writing through symlink": {
+			entries: []tarEntry{
+				{name: "real/", typeflag: tar.TypeDir},
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "real"},
+				{name: "link/file.txt", typeflag: tar.TypeReg, data: "..."},
+			},
+			expectedError: `invalid archive: "link/file.txt" is located through the symbolic link "link"`,
+		},
+
+		"hard link to missing file": {
+			entries:       []tarEntry{{name: "passwd", typeflag: tar.TypeLink, linkname: "missing"}},
+			expectedError: `invalid archive: hard link "passwd" must point to a regular file previously extracted`,
+		},
+
+		"hard link escaping destination dir": {
+			entries:       []tarEntry{{name: "passwd", typeflag: tar.TypeLink, linkname: "../../etc/passwd"}},
+			expectedError: `invalid archive: "../../etc/passwd" is outside of destination dir`,
+		},
+
+		"unsupported file type": {
+			entries:       []tarEntry{{name: "fifo", typeflag: tar.TypeFifo}},
+			expectedError: `invalid archive: not supported file type at file "fifo"`,
+		},
+
+		"too many files": {
+			entries: []tarEntry{
+				{name: "a.txt", typeflag: tar.TypeReg},
+				{name: "b.txt", typeflag: tar.TypeReg},
+				{name: "c.txt", typeflag: tar.TypeReg},
+			},
+			opts:          ExtractOptions{MaxFiles: 2},
+			expectedError: "invalid archive: number of files exceeds the limit of 2",
+		},
+
+		"too large": {
+			entries: []tarEntry{
+				{name: "a.txt", typeflag: tar.TypeReg, data: "0123456789"},
+				{name: "b.txt", typeflag: tar.TypeReg, data: "0123456789"},
+			},
+			opts:          ExtractOptions{MaxSize: 15},
+			expectedError: "invalid archive: size of files exceeds the limit of 15 bytes",
+		},
+
+		"compression bomb": {
+			entries:       []tarEntry{{name: "zeros", typeflag: tar.TypeReg, data: strings.Repeat("\x00", 4<<20)}},
+			opts:          ExtractOptions{MaxCompressionRatio: 10},
+			expectedError: "invalid archive: compression ratio exceeds the limit of 10",
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data := tt.data
+			if data == nil {
+				data = newTarGZ(t, tt.entries).Bytes()
+			}
+
+			err := ExtractGZIPFileToDir(context.TODO(), bytes.NewReader(data), t.TempDir(), tt.opts)
+			require.EqualError(t, err, tt.expectedError)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestCompressGZIPFile(t *testing.T) {
+	t.Run("reproducible mode gives the same tarball for the same content", func(t *testing.T) {
+		compress := func(mtime time.Time) []byte {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "app", "static"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "main.py"), []byte("print('hello world')"), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "static", "index.html"), []byte("<h1>Hello</h1>"), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "Procfile"), []byte("web: python app/main.py"), 0644))
+
+			require.NoError(t, filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				return os.Chtimes(path, mtime, mtime)
+			}))
+
+			var b bytes.Buffer
+			require.NoError(t, CompressGZIPFile(context.TODO(), &b, dir, CompressOptions{Reproducible: true, ModTime: time.Unix(1700000000, 0)}))
+			return b.Bytes()
+		}
+
+		first, second := compress(time.Now()), compress(time.Now().Add(-time.Hour))
+		assert.Equal(t, first, second)
+
+		a, err := NewArchive(first)
+		require.NoError(t, err)
+
+		var names []string
+		err = a.Walk(context.TODO(), func(h *tar.Header, _ io.Reader) error {
+			names = append(names, h.Name)
+			assert.Equal(t, 0, h.Uid)
+			assert.Equal(t, 0, h.Gid)
+			assert.Empty(t, h.Uname)
+			assert.Equal(t, time.Unix(1700000000, 0), h.ModTime)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Procfile", "app/", "app/main.py", "app/static/", "app/static/index.html"}, names)
+	})
+
+	t.Run("mtime from SOURCE_DATE_EPOCH", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Procfile"), []byte("web: ./server"), 0644))
+
+		var b bytes.Buffer
+		require.NoError(t, CompressGZIPFile(context.TODO(), &b, dir, CompressOptions{Reproducible: true}))
+
+		a, err := NewArchive(b.Bytes())
+		require.NoError(t, err)
+
+		err = a.Walk(context.TODO(), func(h *tar.Header, _ io.Reader) error {
+			assert.Equal(t, time.Unix(1600000000, 0), h.ModTime)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid SOURCE_DATE_EPOCH", func(t *testing.T) {
+		t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+		err := CompressGZIPFile(context.TODO(), io.Discard, t.TempDir(), CompressOptions{Reproducible: true})
+		require.EqualError(t, err, `SOURCE_DATE_EPOCH must be a non-negative integer: "yesterday"`)
+	})
+}
+
+func newTarGZ(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	t.Helper()
+
+	var b bytes.Buffer
+	z := gzip.NewWriter(&b)
+	tw := tar.NewWriter(z)
+
+	for _, e := range entries {
+		mode := int64(0644)
+		if e.typeflag == tar.TypeDir {
+			mode = 0755
+		}
+
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+			Size:     int64(len(e.data)),
+			Mode:     mode,
+			Uid:      os.Getuid(),
+			Gid:      os.Getgid(),
+		}))
+
+		_, err := tw.Write([]byte(e.data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, z.Close())
+
+	return &b
+}
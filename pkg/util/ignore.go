@@ -0,0 +1,99 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/dockerignore"
+	"github.com/moby/patternmatcher"
+)
+
+// ExcludedStats sums up the entries left out of an archive.
+type ExcludedStats struct {
+	// Files is the number of entries, except dirs.
+	Files int
+	// Size is the sum of file sizes in bytes (uncompressed).
+	Size int64
+}
+
+// ExcludeIgnoredFiles reads the ignore file (e.g. .dockerignore) at the root
+// of archive and returns a view of it without the entries matched by those
+// patterns, using the same syntax and matcher as BuildKit. When there's no
+// ignore file, the archive is returned as is.
+func ExcludeIgnoredFiles(ctx context.Context, a *Archive, ignoreFile string) (*Archive, ExcludedStats, error) {
+	var patterns []string
+	var found bool
+
+	err := a.Walk(ctx, func(h *tar.Header, r io.Reader) error {
+		if h.Typeflag != tar.TypeReg || archivePath(h.Name) != ignoreFile {
+			return nil
+		}
+
+		var nerr error
+		patterns, nerr = dockerignore.ReadAll(r)
+		found = true
+		return nerr
+	})
+	if err != nil {
+		return nil, ExcludedStats{}, err
+	}
+
+	if !found || len(patterns) == 0 {
+		return a, ExcludedStats{}, nil
+	}
+
+	pm, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, ExcludedStats{}, invalidArchive("invalid pattern in %s: %s", ignoreFile, err)
+	}
+
+	filtered := &Archive{data: a.data, format: a.format, exclude: pm, limits: a.limits}
+
+	var stats ExcludedStats
+	err = a.Walk(ctx, func(h *tar.Header, _ io.Reader) error {
+		excluded, nerr := filtered.excluded(h)
+		if nerr != nil || !excluded || h.Typeflag == tar.TypeDir {
+			return nerr
+		}
+
+		stats.Files++
+		stats.Size += h.Size
+		return nil
+	})
+	if err != nil {
+		return nil, ExcludedStats{}, err
+	}
+
+	return filtered, stats, nil
+}
+
+func (a *Archive) excluded(h *tar.Header) (bool, error) {
+	if a.exclude == nil {
+		return false, nil
+	}
+
+	name := archivePath(h.Name)
+	if name == "" { // root dir
+		return false, nil
+	}
+
+	excluded, err := a.exclude.MatchesOrParentMatches(name)
+	if err != nil {
+		return false, invalidArchive("failed to match %q against exclude patterns: %s", h.Name, err)
+	}
+
+	return excluded, nil
+}
+
+// archivePath returns the entry name relative to the archive root, e.g.
+// "./app/main.py" and "app/main.py" become "app/main.py".
+func archivePath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
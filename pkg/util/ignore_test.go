@@ -0,0 +1,96 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tsuru/deploy-agent/pkg/util"
+)
+
+func TestExcludeIgnoredFiles(t *testing.T) {
+	t.Parallel()
+
+	entries := []tarEntry{
+		{name: "./", typeflag: tar.TypeDir},
+		{name: "./.dockerignore", typeflag: tar.TypeReg, data: "# comment\n.git\nnode_modules/\n*.log\n!important.log\n"},
+		{name: "./.git/", typeflag: tar.TypeDir},
+		{name: "./.git/HEAD", typeflag: tar.TypeReg, data: "ref: refs/heads/main"},
+		{name: "./node_modules/", typeflag: tar.TypeDir},
+		{name: "./node_modules/left-pad/index.js", typeflag: tar.TypeReg, data: "module.exports = leftPad"},
+		{name: "./app.js", typeflag: tar.TypeReg, data: "console.log('hello')"},
+		{name: "./debug.log", typeflag: tar.TypeReg, data: "..."},
+		{name: "./important.log", typeflag: tar.TypeReg, data: "!!!"},
+	}
+
+	cases := map[string]struct {
+		data          []byte
+		ignoreFile    string
+		expected      []string
+		expectedStats ExcludedStats
+	}{
+		"no ignore file": {
+			data:       newTarGZ(t, entries).Bytes(),
+			ignoreFile: ".tsuruignore",
+			expected:   []string{"./", "./.dockerignore", "./.git/", "./.git/HEAD", "./node_modules/", "./node_modules/left-pad/index.js", "./app.js", "./debug.log", "./important.log"},
+		},
+
+		"dockerignore in tar.gz": {
+			data:          newTarGZ(t, entries).Bytes(),
+			ignoreFile:    ".dockerignore",
+			expected:      []string{"./", "./.dockerignore", "./app.js", "./important.log"},
+			expectedStats: ExcludedStats{Files: 3, Size: 47},
+		},
+
+		"dockerignore in zip": {
+			data:          newZip(t, entries[1:]),
+			ignoreFile:    ".dockerignore",
+			expected:      []string{"./.dockerignore", "./app.js", "./important.log"},
+			expectedStats: ExcludedStats{Files: 3, Size: 47},
+		},
+	}
+
+	for name, tt := range cases {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			a, err := NewArchive(tt.data)
+			require.NoError(t, err)
+
+			filtered, stats, err := ExcludeIgnoredFiles(context.TODO(), a, tt.ignoreFile)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStats, stats)
+			assert.Equal(t, tt.expected, archiveNames(t, filtered))
+
+			var b bytes.Buffer
+			require.NoError(t, filtered.WriteTarGZIP(context.TODO(), &b, CompressOptions{}))
+
+			converted, err := NewArchive(b.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, archiveNames(t, converted))
+		})
+	}
+}
+
+func archiveNames(t *testing.T, a *Archive) []string {
+	t.Helper()
+
+	var names []string
+	err := a.Walk(context.TODO(), func(h *tar.Header, _ io.Reader) error {
+		names = append(names, h.Name)
+		return nil
+	})
+	require.NoError(t, err)
+
+	return names
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// LinePrefixWriter writes the result of prefix at the beginning of every line
+// written to the underlying writer, e.g. timestamps. Prefix is called when the
+// first byte of the line is written.
+type LinePrefixWriter struct {
+	w       io.Writer
+	prefix  func() string
+	midLine bool
+	mu      sync.Mutex
+}
+
+func NewLinePrefixWriter(w io.Writer, prefix func() string) *LinePrefixWriter {
+	return &LinePrefixWriter{w: w, prefix: prefix}
+}
+
+func (lw *LinePrefixWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	var b bytes.Buffer
+	for data := p; len(data) > 0; {
+		if !lw.midLine {
+			b.WriteString(lw.prefix())
+			lw.midLine = true
+		}
+
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			b.Write(data)
+			break
+		}
+
+		b.Write(data[:i+1])
+		data = data[i+1:]
+		lw.midLine = false
+	}
+
+	if _, err := lw.w.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/tsuru/deploy-agent/pkg/util"
+)
+
+func TestLinePrefixWriter(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+
+	var n int
+	w := NewLinePrefixWriter(&b, func() string {
+		n++
+		return fmt.Sprintf("[%d] ", n)
+	})
+
+	for _, s := range []string{"#1 [internal] load ", "build definition\n#1 DONE 0.0s\n", "", "\n", "#2 ..."} {
+		written, err := io.WriteString(w, s)
+		require.NoError(t, err)
+		assert.Equal(t, len(s), written)
+	}
+
+	assert.Equal(t, "[1] #1 [internal] load build definition\n[2] #1 DONE 0.0s\n[3] \n[4] #2 ...", b.String())
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// RedactedPlaceholder replaces the secrets in the output.
+	RedactedPlaceholder = "***"
+	// RedactMinLength is the min length of redacted secrets, shorter ones
+	// (e.g. "1", "true") would mostly hide unrelated output.
+	RedactMinLength = 6
+)
+
+// RedactingWriter replaces the secrets in everything written to the
+// underlying writer with RedactedPlaceholder. As a secret may be split across
+// writes, the output tail which could be the beginning of a secret is held
+// until the next write or Flush.
+type RedactingWriter struct {
+	w       io.Writer
+	secrets map[byte][]string // by first byte, longest first
+	pending []byte
+	mu      sync.Mutex
+}
+
+// NewRedactingWriter returns a writer which redacts secrets from w. Each line
+// of multi-line secrets (e.g. PEM keys) is redacted on its own, since output
+// lines are often prefixed (e.g. by BuildKit's progress printer).
+func NewRedactingWriter(w io.Writer, secrets []string) *RedactingWriter {
+	rw := &RedactingWriter{w: w, secrets: make(map[byte][]string)}
+
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		for _, line := range strings.Split(secret, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) < RedactMinLength || seen[line] {
+				continue
+			}
+
+			seen[line] = true
+			rw.secrets[line[0]] = append(rw.secrets[line[0]], line)
+		}
+	}
+
+	for _, candidates := range rw.secrets {
+		sort.SliceStable(candidates, func(i, j int) bool { return len(candidates[i]) > len(candidates[j]) })
+	}
+
+	return rw
+}
+
+func (rw *RedactingWriter) Write(p []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if len(rw.secrets) == 0 {
+		return rw.w.Write(p)
+	}
+
+	rw.pending = append(rw.pending, p...)
+
+	out, rest := rw.redact(rw.pending, false)
+	rw.pending = rw.pending[:copy(rw.pending, rest)]
+
+	if len(out) > 0 {
+		if _, err := rw.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes the held output, if any.
+func (rw *RedactingWriter) Flush() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if len(rw.pending) == 0 {
+		return nil
+	}
+
+	out, _ := rw.redact(rw.pending, true)
+	rw.pending = rw.pending[:0]
+
+	_, err := rw.w.Write(out)
+	return err
+}
+
+// redact returns data with secrets replaced and, unless it's the final data,
+// the tail which could be the beginning of a secret.
+func (rw *RedactingWriter) redact(data []byte, final bool) ([]byte, []byte) {
+	out := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); {
+		candidates := rw.secrets[data[i]]
+		if len(candidates) == 0 {
+			out = append(out, data[i])
+			i++
+			continue
+		}
+
+		tail := data[i:]
+		if !final && isSecretPrefix(tail, candidates) {
+			return out, tail
+		}
+
+		if n := matchSecret(tail, candidates); n > 0 {
+			out = append(out, RedactedPlaceholder...)
+			i += n
+			continue
+		}
+
+		out = append(out, data[i])
+		i++
+	}
+
+	return out, nil
+}
+
+// isSecretPrefix reports whether data is the beginning of any secret.
+func isSecretPrefix(data []byte, secrets []string) bool {
+	for _, s := range secrets {
+		if len(s) > len(data) && strings.HasPrefix(s, string(data)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSecret returns the length of the longest secret at the beginning of
+// data, or 0 when there's none.
+func matchSecret(data []byte, secrets []string) int {
+	for _, s := range secrets {
+		if len(data) >= len(s) && string(data[:len(s)]) == s {
+			return len(s)
+		}
+	}
+
+	return 0
+}